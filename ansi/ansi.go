@@ -0,0 +1,172 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: http://invisible-island.net/xterm/ctlseqs/ctlseqs.html */
+
+// Package ansi builds the control sequences of ANSI X3.64 (ECMA-48) and of the
+// DEC terminals, which are supported by the terminal emulators in use.
+//
+// The functions return the sequence as a string, to be written to the
+// terminal:
+//
+//   io.WriteString(os.Stdout, ansi.CursorUp(2)+ansi.EraseLine(ansi.EraseAll))
+//
+// The rows and the columns start at 1, like in the terminals. The relative
+// movements and the editing functions return an empty string for a count
+// lower than 1, since the terminals handle a parameter of 0 like 1.
+//
+// The output of an application can be parsed through a Parser, and its text got
+// without escape sequences through Strip.
+package ansi
+
+import "strconv"
+
+// Introducers of the control sequences.
+const (
+	ESC = "\033"
+	CSI = ESC + "[" // Control Sequence Introducer
+	OSC = ESC + "]" // Operating System Command
+	DCS = ESC + "P" // Device Control String
+	ST  = ESC + `\` // String Terminator
+)
+
+// csi returns the control sequence with a numeric parameter, which is omitted
+// when it is 1 since it is the value by default. It returns an empty string
+// when n is lower than 1.
+func csi(n int, final string) string {
+	switch {
+	case n < 1:
+		return ""
+	case n == 1:
+		return CSI + final
+	}
+	return CSI + strconv.Itoa(n) + final
+}
+
+// == Cursor
+//
+
+// Save and restore of the cursor position, and of the attributes (DECSC and
+// DECRC).
+const (
+	SaveCursor    = ESC + "7"
+	RestoreCursor = ESC + "8"
+)
+
+// CursorUp moves the cursor up n rows, in the same column (CUU).
+func CursorUp(n int) string { return csi(n, "A") }
+
+// CursorDown moves the cursor down n rows, in the same column (CUD).
+func CursorDown(n int) string { return csi(n, "B") }
+
+// CursorForward moves the cursor forward n columns (CUF).
+func CursorForward(n int) string { return csi(n, "C") }
+
+// CursorBackward moves the cursor backward n columns (CUB).
+func CursorBackward(n int) string { return csi(n, "D") }
+
+// CursorNextLine moves the cursor to the first column, n rows down (CNL).
+func CursorNextLine(n int) string { return csi(n, "E") }
+
+// CursorPreviousLine moves the cursor to the first column, n rows up (CPL).
+func CursorPreviousLine(n int) string { return csi(n, "F") }
+
+// CursorColumn moves the cursor to the column, in the same row (CHA).
+func CursorColumn(column int) string {
+	if column <= 1 {
+		return CSI + "G"
+	}
+	return CSI + strconv.Itoa(column) + "G"
+}
+
+// CursorPosition moves the cursor to the row and column (CUP).
+func CursorPosition(row, column int) string {
+	if row <= 1 && column <= 1 {
+		return CSI + "H"
+	}
+	if row < 1 {
+		row = 1
+	}
+	if column <= 1 {
+		return CSI + strconv.Itoa(row) + "H"
+	}
+	return CSI + strconv.Itoa(row) + ";" + strconv.Itoa(column) + "H"
+}
+
+// == Erase
+//
+
+// EraseMode represents the part to erase of the line or of the display.
+type EraseMode int
+
+const (
+	EraseToEnd   EraseMode = iota // From the cursor to the end.
+	EraseToStart                  // From the start to the cursor.
+	EraseAll                      // All.
+	EraseSaved                    // The lines saved in the scrollback; only for the display.
+)
+
+// EraseLine erases the line (EL). The cursor is not moved.
+func EraseLine(m EraseMode) string {
+	if m == EraseToEnd {
+		return CSI + "K"
+	}
+	return CSI + strconv.Itoa(int(m)) + "K"
+}
+
+// EraseDisplay erases the display (ED). The cursor is not moved.
+func EraseDisplay(m EraseMode) string {
+	if m == EraseToEnd {
+		return CSI + "J"
+	}
+	return CSI + strconv.Itoa(int(m)) + "J"
+}
+
+// EraseChars erases n characters from the cursor, without moving the rest of
+// the line (ECH).
+func EraseChars(n int) string { return csi(n, "X") }
+
+// == Editing
+//
+
+// InsertChars inserts n blank characters at the cursor, moving the rest of the
+// line to the right (ICH).
+func InsertChars(n int) string { return csi(n, "@") }
+
+// DeleteChars deletes n characters from the cursor, moving the rest of the line
+// to the left (DCH).
+func DeleteChars(n int) string { return csi(n, "P") }
+
+// InsertLines inserts n blank lines at the cursor, inside the scroll region
+// (IL).
+func InsertLines(n int) string { return csi(n, "L") }
+
+// DeleteLines deletes n lines from the cursor, inside the scroll region (DL).
+func DeleteLines(n int) string { return csi(n, "M") }
+
+// == Scroll
+//
+
+// ScrollUp scrolls up the scroll region n lines (SU).
+func ScrollUp(n int) string { return csi(n, "S") }
+
+// ScrollDown scrolls down the scroll region n lines (SD).
+func ScrollDown(n int) string { return csi(n, "T") }
+
+// ResetScrollRegion sets the scroll region to the whole display.
+const ResetScrollRegion = CSI + "r"
+
+// SetScrollRegion sets the scroll region from the top row to the bottom one
+// (DECSTBM). The cursor is moved to the home position.
+func SetScrollRegion(top, bottom int) string {
+	if top < 1 {
+		top = 1
+	}
+	if bottom < top {
+		return ResetScrollRegion
+	}
+	return CSI + strconv.Itoa(top) + ";" + strconv.Itoa(bottom) + "r"
+}
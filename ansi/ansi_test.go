@@ -0,0 +1,56 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ansi
+
+import "testing"
+
+func TestSequences(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{CursorUp(1), "\033[A"},
+		{CursorUp(3), "\033[3A"},
+		{CursorUp(0), ""},
+		{CursorDown(-1), ""},
+		{CursorForward(12), "\033[12C"},
+		{CursorBackward(1), "\033[D"},
+		{CursorNextLine(2), "\033[2E"},
+		{CursorPreviousLine(1), "\033[F"},
+		{CursorColumn(0), "\033[G"},
+		{CursorColumn(8), "\033[8G"},
+		{CursorPosition(1, 1), "\033[H"},
+		{CursorPosition(5, 1), "\033[5H"},
+		{CursorPosition(0, 10), "\033[1;10H"},
+		{CursorPosition(5, 10), "\033[5;10H"},
+
+		{EraseLine(EraseToEnd), "\033[K"},
+		{EraseLine(EraseAll), "\033[2K"},
+		{EraseDisplay(EraseToStart), "\033[1J"},
+		{EraseDisplay(EraseSaved), "\033[3J"},
+		{EraseChars(4), "\033[4X"},
+
+		{InsertChars(1), "\033[@"},
+		{DeleteChars(2), "\033[2P"},
+		{InsertLines(3), "\033[3L"},
+		{DeleteLines(0), ""},
+		{ScrollUp(1), "\033[S"},
+		{ScrollDown(2), "\033[2T"},
+		{SetScrollRegion(2, 20), "\033[2;20r"},
+		{SetScrollRegion(5, 4), "\033[r"},
+
+		{SetMode(ShowCursor), "\033[?25h"},
+		{SetMode(MouseNormal, MouseSGR), "\033[?1000;1006h"},
+		{ResetMode(AltScreen), "\033[?1049l"},
+		{ResetMode(), ""},
+	}
+
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("#%d: expected %q, got %q", i, tt.want, tt.got)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ansi
+
+import "strconv"
+
+// Mode represents a DEC private mode.
+type Mode int
+
+const (
+	CursorKeys     Mode = 1    // Application cursor keys (DECCKM).
+	Origin         Mode = 6    // Cursor relative to the scroll region (DECOM).
+	AutoWrap       Mode = 7    // Wrap at the end of the line (DECAWM).
+	MouseX10       Mode = 9    // Mouse reports at button press.
+	BlinkingCursor Mode = 12   // Blinking cursor.
+	ShowCursor     Mode = 25   // Visible cursor (DECTCEM).
+	MouseNormal    Mode = 1000 // Mouse reports at button press and release.
+	MouseButton    Mode = 1002 // Like MouseNormal, plus motion with a button pressed.
+	MouseAnyMotion Mode = 1003 // Like MouseNormal, plus any motion.
+	FocusEvents    Mode = 1004 // Reports of focus in and out.
+	MouseSGR       Mode = 1006 // Mouse reports in format SGR.
+	MouseURXVT     Mode = 1015 // Mouse reports in format of urxvt.
+	AltScreen      Mode = 1049 // Alternate screen, saving the cursor.
+	BracketedPaste Mode = 2004 // Pasted text between delimiters.
+)
+
+// SetMode sets the DEC private modes (DECSET).
+func SetMode(modes ...Mode) string { return privateMode('h', modes) }
+
+// ResetMode resets the DEC private modes (DECRST).
+func ResetMode(modes ...Mode) string { return privateMode('l', modes) }
+
+func privateMode(final byte, modes []Mode) string {
+	if len(modes) == 0 {
+		return ""
+	}
+
+	b := []byte(CSI + "?")
+	for i, m := range modes {
+		if i != 0 {
+			b = append(b, ';')
+		}
+		b = strconv.AppendInt(b, int64(m), 10)
+	}
+	return string(append(b, final))
+}
@@ -0,0 +1,423 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: http://vt100.net/emu/dec_ansi_parser */
+
+package ansi
+
+import "unicode/utf8"
+
+// A Handler handles the elements got by a Parser.
+//
+// The slices passed to the methods are only valid until the method returns,
+// since they are reused by the parser.
+type Handler interface {
+	// Print handles a printable character.
+	Print(r rune)
+
+	// Execute handles a C0 control character, like "\n" or "\b".
+	Execute(b byte)
+
+	// CSIDispatch handles a control sequence, "CSI params intermediates final".
+	// The private markers, like '?' in "CSI ? 25 h", are in intermediates.
+	CSIDispatch(params []int, intermediates []byte, final byte)
+
+	// ESCDispatch handles an escape sequence, "ESC intermediates final".
+	ESCDispatch(intermediates []byte, final byte)
+
+	// OSCDispatch handles an operating system command, with the data between
+	// "ESC ]" and the terminator, ST or BEL.
+	OSCDispatch(data []byte)
+
+	// DCSHook handles the start of a device control string, whose data is
+	// passed to DCSPut until DCSUnhook is called.
+	DCSHook(params []int, intermediates []byte, final byte)
+	DCSPut(b byte)
+	DCSUnhook()
+}
+
+// NopHandler is a Handler which does nothing, to be embedded in the handlers
+// which only need some methods.
+type NopHandler struct{}
+
+func (NopHandler) Print(r rune)                                               {}
+func (NopHandler) Execute(b byte)                                             {}
+func (NopHandler) CSIDispatch(params []int, intermediates []byte, final byte) {}
+func (NopHandler) ESCDispatch(intermediates []byte, final byte)               {}
+func (NopHandler) OSCDispatch(data []byte)                                    {}
+func (NopHandler) DCSHook(params []int, intermediates []byte, final byte)     {}
+func (NopHandler) DCSPut(b byte)                                              {}
+func (NopHandler) DCSUnhook()                                                 {}
+
+type parserState uint8
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateEscapeIntermediate
+	stateCSIEntry
+	stateCSIParam
+	stateCSIIntermediate
+	stateCSIIgnore
+	stateDCSEntry
+	stateDCSParam
+	stateDCSIntermediate
+	stateDCSPassthrough
+	stateDCSIgnore
+	stateOSCString
+	stateSOSPMAPCString
+)
+
+// Limits of the sequences. The parameters and intermediates beyond them are
+// ignored, like in the DEC terminals.
+const (
+	maxParams        = 32
+	maxParamValue    = 65535
+	maxIntermediates = 4
+	maxOSC           = 4096
+)
+
+// A Parser parses a stream of bytes written by an application to a terminal,
+// following the state machine of the DEC VT500 terminals, and passes the
+// elements found to a Handler.
+//
+// The input is decoded as UTF-8, so the 8-bit C1 controls are not recognized;
+// the invalid bytes are printed as utf8.RuneError. The subparameters separated
+// by ':', like in "CSI 4:3 m", are passed like separated parameters, and they
+// are distinguished through Subparam. The OSC data longer than 4096 bytes is
+// truncated.
+//
+// The parser does not allocate memory, so it can be used in the hot paths.
+type Parser struct {
+	h     Handler
+	state parserState
+
+	params      [maxParams]int
+	nParams     int
+	hasParam    bool   // there is a parameter being parsed
+	subparams   uint32 // bit set for the parameters preceded by ':'
+	inter       [maxIntermediates]byte
+	nInter      int
+	interIgnore bool // there are too many intermediates
+
+	osc  [maxOSC]byte
+	nOSC int
+
+	utf8  [utf8.UTFMax]byte
+	nUTF8 int
+}
+
+// NewParser returns a parser which passes the elements to h.
+func NewParser(h Handler) *Parser {
+	return &Parser{h: h}
+}
+
+// Reset sets the parser to the initial state, discarding a sequence not
+// completed.
+func (p *Parser) Reset() {
+	p.state = stateGround
+	p.nUTF8 = 0
+	p.clear()
+}
+
+// InGround reports whether the parser is not inside of a sequence, so the
+// input written until now has been handled completely.
+func (p *Parser) InGround() bool {
+	return p.state == stateGround && p.nUTF8 == 0
+}
+
+// Subparam reports whether the parameter i, passed to CSIDispatch or DCSHook,
+// is a subparameter of the previous one, separated by ':'. It is only valid
+// while the method is called.
+func (p *Parser) Subparam(i int) bool {
+	return i > 0 && i < len(p.params) && p.subparams&(1<<uint(i)) != 0
+}
+
+// Write parses the bytes. It always returns len(b) and a nil error; the
+// sequences can be split in several calls.
+func (p *Parser) Write(b []byte) (int, error) {
+	for _, c := range b {
+		p.Advance(c)
+	}
+	return len(b), nil
+}
+
+// WriteString is like Write, but it parses the bytes of a string.
+func (p *Parser) WriteString(s string) (int, error) {
+	for i := 0; i < len(s); i++ {
+		p.Advance(s[i])
+	}
+	return len(s), nil
+}
+
+// Advance parses a byte.
+func (p *Parser) Advance(c byte) {
+	if p.nUTF8 != 0 {
+		if c&0xC0 == 0x80 {
+			p.utf8[p.nUTF8] = c
+			p.nUTF8++
+			if utf8.FullRune(p.utf8[:p.nUTF8]) {
+				r, _ := utf8.DecodeRune(p.utf8[:p.nUTF8])
+				p.nUTF8 = 0
+				p.h.Print(r)
+			}
+			return
+		}
+		// An incomplete character, followed by the byte to parse.
+		p.nUTF8 = 0
+		p.h.Print(utf8.RuneError)
+	}
+
+	// Transitions from any state.
+	switch c {
+	case 0x18, 0x1A: // CAN, SUB
+		p.exitString()
+		p.h.Execute(c)
+		p.state = stateGround
+		return
+	case 0x1B: // ESC
+		p.exitString()
+		p.clear()
+		p.state = stateEscape
+		return
+	}
+
+	switch p.state {
+	case stateGround:
+		switch {
+		case c < 0x20:
+			p.h.Execute(c)
+		case c < 0x7F:
+			p.h.Print(rune(c))
+		case c == 0x7F:
+		default:
+			p.startRune(c)
+		}
+
+	case stateEscape:
+		switch {
+		case c < 0x20:
+			p.h.Execute(c)
+		case c < 0x30:
+			p.collect(c)
+			p.state = stateEscapeIntermediate
+		case c == '[':
+			p.state = stateCSIEntry
+		case c == ']':
+			p.nOSC = 0
+			p.state = stateOSCString
+		case c == 'P':
+			p.state = stateDCSEntry
+		case c == 'X', c == '^', c == '_':
+			p.state = stateSOSPMAPCString
+		case c == '\\': // ST, which ends a string already handled
+			p.state = stateGround
+		case c < 0x7F:
+			p.escDispatch(c)
+		}
+
+	case stateEscapeIntermediate:
+		switch {
+		case c < 0x20:
+			p.h.Execute(c)
+		case c < 0x30:
+			p.collect(c)
+		case c < 0x7F:
+			p.escDispatch(c)
+		}
+
+	case stateCSIEntry, stateCSIParam:
+		switch {
+		case c < 0x20:
+			p.h.Execute(c)
+		case c < 0x30:
+			p.collect(c)
+			p.state = stateCSIIntermediate
+		case c < 0x3C:
+			p.param(c)
+			p.state = stateCSIParam
+		case c < 0x40:
+			// The private markers are only valid at the start.
+			if p.state == stateCSIParam {
+				p.state = stateCSIIgnore
+			} else {
+				p.collect(c)
+				p.state = stateCSIParam
+			}
+		case c < 0x7F:
+			p.csiDispatch(c)
+		}
+
+	case stateCSIIntermediate:
+		switch {
+		case c < 0x20:
+			p.h.Execute(c)
+		case c < 0x30:
+			p.collect(c)
+		case c < 0x40:
+			p.state = stateCSIIgnore
+		case c < 0x7F:
+			p.csiDispatch(c)
+		}
+
+	case stateCSIIgnore:
+		switch {
+		case c < 0x20:
+			p.h.Execute(c)
+		case c >= 0x40 && c < 0x7F:
+			p.state = stateGround
+		}
+
+	case stateDCSEntry, stateDCSParam:
+		switch {
+		case c < 0x20:
+		case c < 0x30:
+			p.collect(c)
+			p.state = stateDCSIntermediate
+		case c < 0x3C:
+			p.param(c)
+			p.state = stateDCSParam
+		case c < 0x40:
+			if p.state == stateDCSParam {
+				p.state = stateDCSIgnore
+			} else {
+				p.collect(c)
+				p.state = stateDCSParam
+			}
+		case c < 0x7F:
+			p.dcsHook(c)
+		}
+
+	case stateDCSIntermediate:
+		switch {
+		case c < 0x20:
+		case c < 0x30:
+			p.collect(c)
+		case c < 0x40:
+			p.state = stateDCSIgnore
+		case c < 0x7F:
+			p.dcsHook(c)
+		}
+
+	case stateDCSPassthrough:
+		if c != 0x7F {
+			p.h.DCSPut(c)
+		}
+
+	case stateOSCString:
+		switch {
+		case c == 0x07: // BEL, used by xterm like terminator
+			p.exitString()
+			p.state = stateGround
+		case c < 0x20:
+		case p.nOSC < len(p.osc):
+			p.osc[p.nOSC] = c
+			p.nOSC++
+		}
+
+	case stateDCSIgnore, stateSOSPMAPCString:
+	}
+}
+
+// startRune starts a multi-byte character.
+func (p *Parser) startRune(c byte) {
+	if c < 0xC2 || c > 0xF4 { // not valid to start a character
+		p.h.Print(utf8.RuneError)
+		return
+	}
+	p.utf8[0] = c
+	p.nUTF8 = 1
+}
+
+// clear clears the parameters and intermediates of the sequence.
+func (p *Parser) clear() {
+	p.nParams = 0
+	p.hasParam = false
+	p.subparams = 0
+	p.nInter = 0
+	p.interIgnore = false
+}
+
+func (p *Parser) collect(c byte) {
+	if p.nInter == len(p.inter) {
+		p.interIgnore = true
+		return
+	}
+	p.inter[p.nInter] = c
+	p.nInter++
+}
+
+func (p *Parser) param(c byte) {
+	if p.nParams == len(p.params) {
+		return
+	}
+	if c == ';' || c == ':' {
+		if !p.hasParam {
+			p.params[p.nParams] = 0
+		}
+		p.nParams++
+		p.hasParam = false
+		if c == ':' && p.nParams < len(p.params) {
+			p.subparams |= 1 << uint(p.nParams)
+		}
+		return
+	}
+
+	if !p.hasParam {
+		p.params[p.nParams] = 0
+		p.hasParam = true
+	}
+	if v := p.params[p.nParams]*10 + int(c-'0'); v <= maxParamValue {
+		p.params[p.nParams] = v
+	} else {
+		p.params[p.nParams] = maxParamValue
+	}
+}
+
+// finishParams returns the parameters, including the last one.
+func (p *Parser) finishParams() []int {
+	n := p.nParams
+	if n < len(p.params) && (p.hasParam || n != 0) {
+		if !p.hasParam {
+			p.params[n] = 0 // empty after ';'
+		}
+		n++
+	}
+	return p.params[:n]
+}
+
+func (p *Parser) escDispatch(c byte) {
+	p.state = stateGround
+	if !p.interIgnore {
+		p.h.ESCDispatch(p.inter[:p.nInter], c)
+	}
+}
+
+func (p *Parser) csiDispatch(c byte) {
+	p.state = stateGround
+	if !p.interIgnore {
+		p.h.CSIDispatch(p.finishParams(), p.inter[:p.nInter], c)
+	}
+}
+
+func (p *Parser) dcsHook(c byte) {
+	if p.interIgnore {
+		p.state = stateDCSIgnore
+		return
+	}
+	p.state = stateDCSPassthrough
+	p.h.DCSHook(p.finishParams(), p.inter[:p.nInter], c)
+}
+
+// exitString finishes the string being parsed, at its terminator.
+func (p *Parser) exitString() {
+	switch p.state {
+	case stateOSCString:
+		p.h.OSCDispatch(p.osc[:p.nOSC])
+	case stateDCSPassthrough:
+		p.h.DCSUnhook()
+	}
+}
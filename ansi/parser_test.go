@@ -0,0 +1,166 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ansi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recorder records the elements handled, in a readable format.
+type recorder struct {
+	out  []string
+	text []rune
+}
+
+func (r *recorder) flush() {
+	if len(r.text) != 0 {
+		r.out = append(r.out, fmt.Sprintf("print %q", string(r.text)))
+		r.text = r.text[:0]
+	}
+}
+
+func (r *recorder) add(format string, a ...interface{}) {
+	r.flush()
+	r.out = append(r.out, fmt.Sprintf(format, a...))
+}
+
+func (r *recorder) Print(c rune)   { r.text = append(r.text, c) }
+func (r *recorder) Execute(b byte) { r.add("exec %#x", b) }
+func (r *recorder) CSIDispatch(params []int, inter []byte, final byte) {
+	r.add("csi %v %q %c", params, inter, final)
+}
+func (r *recorder) ESCDispatch(inter []byte, final byte) { r.add("esc %q %c", inter, final) }
+func (r *recorder) OSCDispatch(data []byte)              { r.add("osc %q", data) }
+func (r *recorder) DCSHook(params []int, inter []byte, final byte) {
+	r.add("hook %v %q %c", params, inter, final)
+}
+func (r *recorder) DCSPut(b byte) { r.add("put %c", b) }
+func (r *recorder) DCSUnhook()    { r.add("unhook") }
+
+var parserTests = []struct {
+	in   string
+	want []string
+}{
+	{"ab\r\n", []string{`print "ab"`, "exec 0xd", "exec 0xa"}},
+	{"ñ€\xff世", []string{"print \"ñ€\uFFFD世\""}},
+	{"\xe2\x82a", []string{"print \"\uFFFDa\""}},
+
+	// CSI
+	{"\033[A\033[12;40H", []string{`csi [] "" A`, `csi [12 40] "" H`}},
+	{"\033[;5H\033[3;m", []string{`csi [0 5] "" H`, `csi [3 0] "" m`}},
+	{"\033[?25h\033[>c", []string{`csi [25] "?" h`, `csi [] ">" c`}},
+	{"\033[38:2::255:0:0m", []string{`csi [38 2 0 255 0 0] "" m`}},
+	{"\033[1 q\033[?1$p", []string{`csi [1] " " q`, `csi [1] "?$" p`}},
+	{"\033[1\n2A", []string{"exec 0xa", `csi [12] "" A`}},
+	{"\033[1?2Ax", []string{`print "x"`}},
+	{"\033[99999999C", []string{`csi [65535] "" C`}},
+	{"\033[1\0302A", []string{"exec 0x18", `print "2A"`}},
+
+	// ESC
+	{"\0337\033(B\033#8", []string{`esc "" 7`, `esc "(" B`, `esc "#" 8`}},
+
+	// Strings
+	{"\033]0;title\a", []string{`osc "0;title"`}},
+	{"\033]11;?\033\\x", []string{`osc "11;?"`, `print "x"`}},
+	{"\033P+q52\033\\", []string{`hook [] "+" q`, "put 5", "put 2", "unhook"}},
+	{"\033P1$r0m\033\\", []string{`hook [1] "$" r`, "put 0", "put m", "unhook"}},
+	{"\033_apc\033\\\033^pm\033\\a", []string{`print "a"`}},
+}
+
+func TestParser(t *testing.T) {
+	for _, tt := range parserTests {
+		var r recorder
+		p := NewParser(&r)
+		p.WriteString(tt.in)
+		r.flush()
+
+		if strings.Join(r.out, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%q:\n got  %q\n want %q", tt.in, r.out, tt.want)
+		}
+		if !p.InGround() {
+			t.Errorf("%q: expected to finish in ground state", tt.in)
+		}
+
+		// Byte to byte
+		var r2 recorder
+		p = NewParser(&r2)
+		for i := 0; i < len(tt.in); i++ {
+			p.Write([]byte{tt.in[i]})
+		}
+		r2.flush()
+		if strings.Join(r2.out, "\n") != strings.Join(r.out, "\n") {
+			t.Errorf("%q: got %q writing byte to byte", tt.in, r2.out)
+		}
+	}
+}
+
+// subparamRecorder records the parameters of SGR, marking the subparameters.
+type subparamRecorder struct {
+	NopHandler
+	p   *Parser
+	out string
+}
+
+func (r *subparamRecorder) CSIDispatch(params []int, inter []byte, final byte) {
+	for i, v := range params {
+		if r.p.Subparam(i) {
+			r.out += ":"
+		} else if i != 0 {
+			r.out += ";"
+		}
+		r.out += fmt.Sprint(v)
+	}
+}
+
+func TestSubparam(t *testing.T) {
+	in := "\033[4:3;38:2::1:2:3;48;5;200;1m"
+	want := "4:3;38:2:0:1:2:3;48;5;200;1"
+
+	var r subparamRecorder
+	r.p = NewParser(&r)
+	r.p.WriteString(in)
+
+	if r.out != want {
+		t.Errorf("expected %q, got %q", want, r.out)
+	}
+}
+
+func TestParserAllocs(t *testing.T) {
+	in := []byte("\033[1;31mred\033[0m \033]0;title\a\033P+q52\033\\ñ\033[?1049h")
+	p := NewParser(NopHandler{})
+
+	if n := testing.AllocsPerRun(100, func() { p.Write(in) }); n != 0 {
+		t.Errorf("expected no allocations, got %v", n)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	in := "\033[1;31merror\033[0m:\tfile\033]8;;http://x\033\\ link\033]8;;\033\\\a\r\n"
+	want := "error:\tfile link\r\n"
+
+	if got := Strip(in); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	var buf bytes.Buffer
+	w := NewStripWriter(&buf)
+	for i := 0; i < len(in); i += 3 {
+		end := i + 3
+		if end > len(in) {
+			end = len(in)
+		}
+		if n, err := w.Write([]byte(in[i:end])); err != nil || n != end-i {
+			t.Fatalf("Write: %d, %v", n, err)
+		}
+	}
+	if buf.String() != want {
+		t.Errorf("StripWriter: expected %q, got %q", want, buf.String())
+	}
+}
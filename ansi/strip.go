@@ -0,0 +1,69 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ansi
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// stripper keeps the text, without the escape sequences.
+type stripper struct {
+	NopHandler
+	buf []byte
+}
+
+func (s *stripper) Print(r rune) {
+	var b [utf8.UTFMax]byte
+	n := utf8.EncodeRune(b[:], r)
+	s.buf = append(s.buf, b[:n]...)
+}
+
+// Execute keeps the controls which format the text.
+func (s *stripper) Execute(b byte) {
+	switch b {
+	case '\t', '\n', '\r':
+		s.buf = append(s.buf, b)
+	}
+}
+
+// Strip returns the text without the escape sequences, like the colors, and
+// without the control characters other than tab, new line and carriage return.
+func Strip(s string) string {
+	st := stripper{buf: make([]byte, 0, len(s))}
+	NewParser(&st).WriteString(s)
+	return string(st.buf)
+}
+
+// A StripWriter writes the text to an io.Writer, without the escape sequences.
+// The sequences can be split in several writes.
+type StripWriter struct {
+	w  io.Writer
+	p  *Parser
+	st stripper
+}
+
+// NewStripWriter returns a StripWriter which writes to w.
+func NewStripWriter(w io.Writer) *StripWriter {
+	sw := &StripWriter{w: w}
+	sw.p = NewParser(&sw.st)
+	return sw
+}
+
+// Write writes the text of b to the underlying writer. It returns len(b) if
+// the text has been written completely.
+func (sw *StripWriter) Write(b []byte) (int, error) {
+	sw.st.buf = sw.st.buf[:0]
+	sw.p.Write(b)
+
+	if len(sw.st.buf) != 0 {
+		if _, err := sw.w.Write(sw.st.buf); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: https://docs.asciinema.org/manual/asciicast/v2/ */
+
+// Package asciicast records terminal sessions in the format asciicast v2, used
+// by asciinema, and replays them, also the ones recorded by the command script.
+//
+// A recording is a header in JSON, followed by an event in each line, like:
+//
+//   {"version": 2, "width": 80, "height": 24, "timestamp": 1504467315}
+//   [0.248848, "o", "hello "]
+//   [1.001376, "o", "world!\r\n"]
+//   [1.553025, "r", "100x30"]
+//
+// The time of an event is the number of seconds since the start of the
+// recording.
+package asciicast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Version is the version of the format written.
+const Version = 2
+
+// Header represents the header of a recording.
+type Header struct {
+	Version   int     `json:"version"`
+	Width     int     `json:"width"`  // columns
+	Height    int     `json:"height"` // rows
+	Timestamp int64   `json:"timestamp,omitempty"`
+	Duration  float64 `json:"duration,omitempty"`
+
+	// The maximum time between events, in seconds, used in the replay.
+	IdleTimeLimit float64 `json:"idle_time_limit,omitempty"`
+
+	Command string            `json:"command,omitempty"`
+	Title   string            `json:"title,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// EventType represents the type of an event.
+type EventType string
+
+const (
+	OutputEvent EventType = "o" // data written to the terminal
+	InputEvent  EventType = "i" // data read from the terminal
+	ResizeEvent EventType = "r" // size of the terminal, like "80x24"
+	MarkerEvent EventType = "m" // label of a position
+)
+
+// Event represents an event of a recording.
+type Event struct {
+	Time float64 // seconds since the start
+	Type EventType
+	Data string
+}
+
+// ResizeData returns the data of a resize event, in the format "COLSxROWS".
+func ResizeData(rows, columns int) string {
+	return fmt.Sprintf("%dx%d", columns, rows)
+}
+
+var errVersion = errors.New("asciicast: the version has to be 2")
+
+// A Writer writes a recording. It is safe for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+// NewWriter returns a writer which writes the header to w. The version is set
+// if it is zero.
+func NewWriter(w io.Writer, h *Header) (*Writer, error) {
+	if h.Version == 0 {
+		h.Version = Version
+	} else if h.Version != Version {
+		return nil, errVersion
+	}
+
+	b, err := json.Marshal(h)
+	if err != nil {
+		return nil, fmt.Errorf("asciicast: could not encode header: %s", err)
+	}
+	if _, err = w.Write(append(b, '\n')); err != nil {
+		return nil, fmt.Errorf("asciicast: could not write header: %s", err)
+	}
+	return &Writer{w: w}, nil
+}
+
+// WriteEvent writes an event in a line.
+func (w *Writer) WriteEvent(ev Event) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return fmt.Errorf("asciicast: could not encode event: %s", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b := append(w.buf[:0], '[')
+	b = strconv.AppendFloat(b, ev.Time, 'f', 6, 64)
+	b = append(b, ", \""...)
+	b = append(b, ev.Type...)
+	b = append(b, "\", "...)
+	b = append(b, data...)
+	b = append(b, "]\n"...)
+	w.buf = b
+
+	if _, err = w.w.Write(b); err != nil {
+		return fmt.Errorf("asciicast: could not write event: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, &Header{Width: 80, Height: 24, Timestamp: 1504467315})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteEvent(Event{0.248848, OutputEvent, "hello "})
+	w.WriteEvent(Event{1.001376, OutputEvent, "world!\r\n\033[0m"})
+	w.WriteEvent(Event{1.5, ResizeEvent, ResizeData(30, 100)})
+
+	want := `{"version":2,"width":80,"height":24,"timestamp":1504467315}
+[0.248848, "o", "hello "]
+[1.001376, "o", "world!\r\n\u001b[0m"]
+[1.500000, "r", "100x30"]
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	if _, err = NewWriter(&buf, &Header{Version: 1}); err == nil {
+		t.Error("expected error for version 1")
+	}
+}
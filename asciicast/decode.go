@@ -0,0 +1,202 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Recording represents a recording loaded in memory.
+type Recording struct {
+	Header Header
+	Events []Event
+}
+
+// Decode reads a recording in format asciicast v2.
+func Decode(r io.Reader) (*Recording, error) {
+	br := bufio.NewReader(r)
+	rec := new(Recording)
+
+	for nline := 1; ; nline++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("asciicast: could not read: %s", err)
+		}
+		eof := err == io.EOF
+
+		if line = bytes.TrimSpace(line); len(line) != 0 {
+			if nline == 1 {
+				if err = json.Unmarshal(line, &rec.Header); err != nil {
+					return nil, fmt.Errorf("asciicast: could not decode header: %s", err)
+				}
+				if rec.Header.Version != Version {
+					return nil, errVersion
+				}
+			} else {
+				ev, err := decodeEvent(line)
+				if err != nil {
+					return nil, fmt.Errorf("asciicast: could not decode event in line %d: %s",
+						nline, err)
+				}
+				rec.Events = append(rec.Events, ev)
+			}
+		} else if nline == 1 {
+			return nil, errors.New("asciicast: no header")
+		}
+
+		if eof {
+			return rec, nil
+		}
+	}
+}
+
+// decodeEvent decodes an event, like [1.001376, "o", "hello"].
+func decodeEvent(line []byte) (ev Event, err error) {
+	var fields []json.RawMessage
+
+	if err = json.Unmarshal(line, &fields); err != nil {
+		return
+	}
+	if len(fields) < 3 {
+		return ev, errors.New("expected 3 fields")
+	}
+
+	if err = json.Unmarshal(fields[0], &ev.Time); err != nil {
+		return
+	}
+	if err = json.Unmarshal(fields[1], &ev.Type); err != nil {
+		return
+	}
+	err = json.Unmarshal(fields[2], &ev.Data)
+	return
+}
+
+// scriptHeader matches the first line written by script, like
+//
+//   Script started on 2020-01-02 10:00:00+01:00 [TERM="xterm" COLUMNS="80" LINES="24"]
+var scriptHeader = regexp.MustCompile(`(COLUMNS|LINES)="(\d+)"`)
+
+// DecodeScript reads a recording of the command script, from the typescript
+// and the timing file got with its option "-t" or "-T".
+//
+// The timing can be in the classic format, with the delay and number of bytes
+// in each line, or in the advanced one of util-linux, where the input is read
+// from the same typescript like with the option "--log-io". The size is got
+// from the information of the typescript, or it is zero.
+func DecodeScript(typescript, timing io.Reader) (*Recording, error) {
+	data, err := ioutil.ReadAll(typescript)
+	if err != nil {
+		return nil, fmt.Errorf("asciicast: could not read typescript: %s", err)
+	}
+	rec := &Recording{Header: Header{Version: Version}}
+
+	if bytes.HasPrefix(data, []byte("Script started")) {
+		info := data
+		data = nil
+		if i := bytes.IndexByte(info, '\n'); i != -1 {
+			info, data = info[:i], info[i+1:]
+		}
+		setScriptSize(&rec.Header, string(info))
+	}
+
+	var t float64
+	sc := bufio.NewScanner(timing)
+
+	for nline := 1; sc.Scan(); nline++ {
+		f := strings.Fields(sc.Text())
+		if len(f) == 0 {
+			continue
+		}
+		errLine := fmt.Errorf("asciicast: invalid timing in line %d", nline)
+
+		// The classic format has not the type of entry.
+		typ := "O"
+		if len(f[0]) == 1 && (f[0][0] < '0' || f[0][0] > '9') {
+			typ, f = f[0], f[1:]
+		}
+		if len(f) < 2 {
+			return nil, errLine
+		}
+		delay, err := strconv.ParseFloat(f[0], 64)
+		if err != nil {
+			return nil, errLine
+		}
+		t += delay
+
+		switch typ {
+		case "O", "I":
+			n, err := strconv.Atoi(f[1])
+			if err != nil || n < 0 {
+				return nil, errLine
+			}
+			if n > len(data) {
+				n = len(data)
+			}
+			evType := OutputEvent
+			if typ == "I" {
+				evType = InputEvent
+			}
+			rec.Events = append(rec.Events, Event{t, evType, string(data[:n])})
+			data = data[n:]
+		case "H": // information, like "H 0.000000 COLUMNS 80"
+			if len(f) >= 3 {
+				setScriptSize(&rec.Header, f[1]+`="`+f[2]+`"`)
+			}
+		case "S": // signal, like "S 1.5 SIGWINCH ROWS=30 COLS=100"
+			if f[1] != "SIGWINCH" {
+				continue
+			}
+			var rows, cols int
+			for _, v := range f[2:] {
+				if strings.HasPrefix(v, "ROWS=") {
+					rows, _ = strconv.Atoi(v[5:])
+				} else if strings.HasPrefix(v, "COLS=") {
+					cols, _ = strconv.Atoi(v[5:])
+				}
+			}
+			if rows != 0 && cols != 0 {
+				rec.Events = append(rec.Events, Event{t, ResizeEvent, ResizeData(rows, cols)})
+			}
+		}
+	}
+	if err = sc.Err(); err != nil {
+		return nil, fmt.Errorf("asciicast: could not read timing: %s", err)
+	}
+	return rec, nil
+}
+
+// setScriptSize sets the size from the information of script.
+func setScriptSize(h *Header, info string) {
+	for _, m := range scriptHeader.FindAllStringSubmatch(info, -1) {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "COLUMNS" {
+			h.Width = n
+		} else {
+			h.Height = n
+		}
+	}
+}
+
+// parseResize parses the data of a resize event.
+func parseResize(data string) (rows, columns int, ok bool) {
+	i := strings.IndexByte(data, 'x')
+	if i == -1 {
+		return
+	}
+	columns, err1 := strconv.Atoi(data[:i])
+	rows, err2 := strconv.Atoi(data[i+1:])
+	return rows, columns, err1 == nil && err2 == nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	rec, err := Decode(strings.NewReader(`{"version": 2, "width": 100, "height": 30, "idle_time_limit": 2}
+[0.5, "o", "hello\r\n"]
+
+[1.25, "r", "80x24"]
+[2, "m", ""]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Recording{
+		Header: Header{Version: 2, Width: 100, Height: 30, IdleTimeLimit: 2},
+		Events: []Event{
+			{0.5, OutputEvent, "hello\r\n"},
+			{1.25, ResizeEvent, "80x24"},
+			{2, MarkerEvent, ""},
+		},
+	}
+	if !reflect.DeepEqual(rec, want) {
+		t.Errorf("got %+v, want %+v", rec, want)
+	}
+
+	for _, s := range []string{
+		"",
+		`{"version": 1, "width": 80, "height": 24}`,
+		`{"version": 2}` + "\n[0.5, \"o\"]",
+		`{"version": 2}` + "\n[\"x\", \"o\", \"a\"]",
+	} {
+		if _, err = Decode(strings.NewReader(s)); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestDecodeScript(t *testing.T) {
+	typescript := `Script started on 2020-01-02 10:00:00+01:00 [TERM="xterm" COLUMNS="100" LINES="30"]
+$ ls
+a  b
+Script done on 2020-01-02 10:00:05+01:00 [COMMAND_EXIT_CODE="0"]
+`
+	rec, err := DecodeScript(strings.NewReader(typescript),
+		strings.NewReader("0.5 2\n1.0 3\n0.25 5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Recording{
+		Header: Header{Version: 2, Width: 100, Height: 30},
+		Events: []Event{
+			{0.5, OutputEvent, "$ "},
+			{1.5, OutputEvent, "ls\n"},
+			{1.75, OutputEvent, "a  b\n"},
+		},
+	}
+	if !reflect.DeepEqual(rec, want) {
+		t.Errorf("classic: got %+v, want %+v", rec, want)
+	}
+
+	// Advanced format, with input.
+	rec, err = DecodeScript(strings.NewReader("$ ls\r\nls\r\n"), strings.NewReader(`H 0.000000 COLUMNS 90
+H 0.000000 LINES 20
+O 0.5 2
+I 1.0 3
+S 0.5 SIGWINCH ROWS=30 COLS=100
+O 0.25 6
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &Recording{
+		Header: Header{Version: 2, Width: 90, Height: 20},
+		Events: []Event{
+			{0.5, OutputEvent, "$ "},
+			{1.5, InputEvent, "ls\r"},
+			{2, ResizeEvent, "100x30"},
+			{2.25, OutputEvent, "\nls\r\n"},
+		},
+	}
+	if !reflect.DeepEqual(rec, want) {
+		t.Errorf("advanced: got %+v, want %+v", rec, want)
+	}
+
+	if _, err = DecodeScript(strings.NewReader(""), strings.NewReader("x 1\n")); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,378 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/kless/terminal/ansi"
+	"github.com/kless/terminal/keys"
+)
+
+// SeekStep is the time moved in the recording by the keys Left and Right.
+const SeekStep = 5 * time.Second
+
+// A Player replays a recording, writing its output at the time of each event.
+//
+// The replay can be controlled with the keys:
+//
+//   Space        pause or resume
+//   .            while paused, go to the next output
+//   ]            go to the next marker
+//   Left, Right  go back or forward by SeekStep
+//   +, -         double or halve the speed
+//   q, Ctrl+C    quit
+//
+// Going back resets the terminal, and writes the output from the start until
+// the new position.
+type Player struct {
+	// Speed is the multiplier of the speed of the replay; 1 by default.
+	Speed float64
+
+	// IdleTimeLimit is the maximum time between events. If it is zero, it is
+	// used the one of the header of the recording, if any.
+	IdleTimeLimit time.Duration
+
+	rec   *Recording
+	w     io.Writer
+	times []float64 // time of each event, limited to IdleTimeLimit
+
+	pos    int       // next event
+	clock  float64   // time of the recording played, at ref
+	ref    time.Time // actual time when clock was set
+	paused bool
+
+	rows, columns int // size of the recording
+
+	parser *ansi.Parser // to know the modes set by the output written
+	modes  modeTracker
+}
+
+// NewPlayer returns a player of the recording.
+func NewPlayer(rec *Recording) *Player {
+	return &Player{Speed: 1, rec: rec}
+}
+
+// Play replays the recording, writing the output to w. It reads the keys from
+// in, if it is not nil, and redraws the output when resize receives, for the
+// changes of the size of the terminal. It returns at the end of the recording,
+// or when the user quits, resetting the modes left set by the output written,
+// like the alternate screen, the reports of the mouse or a hidden cursor.
+//
+// If in is a keys.TimeoutReader, like a terminal, the reading is stopped before
+// of returning, so the input typed after is kept for the caller. Else, a read
+// can be left pending.
+func (p *Player) Play(w io.Writer, in io.Reader, resize <-chan bool) (err error) {
+	var keyc chan keys.KeyEvent
+
+	if in != nil {
+		keyc = make(chan keys.KeyEvent)
+		done := make(chan bool)
+		stopped := make(chan bool)
+
+		tr, cancel := in.(keys.TimeoutReader)
+		if cancel {
+			in = keyReader{tr, done}
+		}
+		dec := keys.NewDecoder(in)
+
+		go func() {
+			defer close(stopped)
+			defer close(keyc)
+			defer dec.Close()
+			for {
+				k, err := dec.ReadKey()
+				if err != nil {
+					return
+				}
+				select {
+				case keyc <- k:
+				case <-done:
+					return
+				}
+			}
+		}()
+		defer func() {
+			close(done)
+			if cancel {
+				<-stopped
+			}
+		}()
+	}
+	defer func() {
+		if reset := p.modes.reset(); reset != "" {
+			if _, e := io.WriteString(w, reset); err == nil {
+				err = e
+			}
+		}
+	}()
+	return p.play(w, keyc, resize)
+}
+
+// keyPoll is the time waited for the input at each read of a keyReader, to
+// check whether the replay has finished.
+const keyPoll = 100 * time.Millisecond
+
+// keyReader reads the keys from a TimeoutReader until done is closed, to not
+// leave a read pending after of playing.
+type keyReader struct {
+	r    keys.TimeoutReader
+	done <-chan bool
+}
+
+func (r keyReader) Read(p []byte) (int, error) {
+	for {
+		select {
+		case <-r.done:
+			return 0, io.EOF
+		default:
+		}
+
+		if n, err := r.r.ReadTimeout(p, keyPoll); n != 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+func (r keyReader) ReadTimeout(p []byte, timeout time.Duration) (int, error) {
+	return r.r.ReadTimeout(p, timeout)
+}
+
+func (p *Player) play(w io.Writer, keyc <-chan keys.KeyEvent, resize <-chan bool) error {
+	p.init(w)
+
+	for p.pos < len(p.rec.Events) {
+		var timer *time.Timer
+		var timeout <-chan time.Time
+
+		if !p.paused {
+			d := (p.times[p.pos] - p.now()) / p.Speed
+			timer = time.NewTimer(time.Duration(d * float64(time.Second)))
+			timeout = timer.C
+		}
+
+		var err error
+		quit := false
+
+		select {
+		case <-timeout:
+			err = p.playUntil(p.now())
+		case k, ok := <-keyc:
+			if !ok {
+				keyc = nil
+				break
+			}
+			quit, err = p.key(k)
+		case <-resize:
+			err = p.seek(p.now(), true)
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil || quit {
+			return err
+		}
+	}
+	return nil
+}
+
+// init sets the player at the start of the recording.
+func (p *Player) init(w io.Writer) {
+	if p.Speed <= 0 {
+		p.Speed = 1
+	}
+	limit := p.IdleTimeLimit.Seconds()
+	if limit == 0 {
+		limit = p.rec.Header.IdleTimeLimit
+	}
+
+	p.w = w
+	p.times = make([]float64, len(p.rec.Events))
+	var last, t float64
+
+	for i, ev := range p.rec.Events {
+		delay := ev.Time - last
+		if limit > 0 && delay > limit {
+			delay = limit
+		}
+		if delay > 0 {
+			t += delay
+		}
+		last = ev.Time
+		p.times[i] = t
+	}
+
+	p.pos, p.paused = 0, false
+	p.rows, p.columns = p.rec.Header.Height, p.rec.Header.Width
+	p.modes.clear()
+	p.parser = ansi.NewParser(&p.modes)
+	p.setClock(0)
+}
+
+// now returns the time of the recording played.
+func (p *Player) now() float64 {
+	if p.paused {
+		return p.clock
+	}
+	return p.clock + time.Since(p.ref).Seconds()*p.Speed
+}
+
+func (p *Player) setClock(t float64) {
+	p.clock, p.ref = t, time.Now()
+}
+
+// playUntil writes the events until the time, included.
+func (p *Player) playUntil(t float64) error {
+	for ; p.pos < len(p.rec.Events) && p.times[p.pos] <= t; p.pos++ {
+		ev := p.rec.Events[p.pos]
+
+		switch ev.Type {
+		case OutputEvent:
+			if _, err := io.WriteString(p.w, ev.Data); err != nil {
+				return err
+			}
+			p.parser.WriteString(ev.Data)
+		case ResizeEvent:
+			if rows, cols, ok := parseResize(ev.Data); ok {
+				p.rows, p.columns = rows, cols
+			}
+		}
+	}
+	return nil
+}
+
+// seek moves to the time. The output is written from the start if it is
+// before of the output written, or to redraw it.
+func (p *Player) seek(t float64, redraw bool) error {
+	if t < 0 {
+		t = 0
+	}
+	if redraw || p.pos > 0 && t < p.times[p.pos-1] {
+		p.pos = 0
+		p.rows, p.columns = p.rec.Header.Height, p.rec.Header.Width
+		if _, err := io.WriteString(p.w, ansi.ESC+"c"); err != nil { // RIS
+			return err
+		}
+		p.parser.Reset()
+		p.modes.clear()
+	}
+	p.setClock(t)
+	return p.playUntil(t)
+}
+
+// key handles a key, and reports whether to quit.
+func (p *Player) key(k keys.KeyEvent) (quit bool, err error) {
+	switch {
+	case k.Key == keys.KeyRune && k.Mod == 0:
+		switch k.Rune {
+		case ' ':
+			p.setClock(p.now())
+			p.paused = !p.paused
+		case '.':
+			if p.paused {
+				err = p.next(OutputEvent)
+			}
+		case ']':
+			err = p.next(MarkerEvent)
+		case '+':
+			p.setClock(p.now())
+			p.Speed *= 2
+		case '-':
+			p.setClock(p.now())
+			p.Speed /= 2
+		case 'q':
+			quit = true
+		}
+	case k.Key == keys.KeyRune && k.Mod == keys.ModCtrl && k.Rune == 'c':
+		quit = true
+	case k.Key == keys.KeyRight && k.Mod == 0:
+		err = p.seek(p.now()+SeekStep.Seconds(), false)
+	case k.Key == keys.KeyLeft && k.Mod == 0:
+		err = p.seek(p.now()-SeekStep.Seconds(), false)
+	}
+	return
+}
+
+// next moves to the next event of the type.
+func (p *Player) next(typ EventType) error {
+	for i := p.pos; i < len(p.rec.Events); i++ {
+		if p.rec.Events[i].Type == typ {
+			return p.seek(p.times[i], false)
+		}
+	}
+	return nil
+}
+
+// Size returns the size of the terminal recorded at the actual position, which
+// is zero if it is unknown.
+func (p *Player) Size() (rows, columns int) {
+	return p.rows, p.columns
+}
+
+// modeTracker is an ansi.Handler which keeps the modes changed by the output,
+// to reset them at the end of the replay.
+type modeTracker struct {
+	ansi.NopHandler
+
+	modes map[ansi.Mode]bool // DEC private modes set or reset
+	attrs bool               // attributes of the text set
+}
+
+func (t *modeTracker) clear() {
+	t.modes = make(map[ansi.Mode]bool)
+	t.attrs = false
+}
+
+func (t *modeTracker) CSIDispatch(params []int, inter []byte, final byte) {
+	switch {
+	case string(inter) == "?" && (final == 'h' || final == 'l'):
+		for _, m := range params {
+			t.modes[ansi.Mode(m)] = final == 'h'
+		}
+	case len(inter) == 0 && final == 'm': // SGR
+		t.attrs = true
+	}
+}
+
+func (t *modeTracker) ESCDispatch(inter []byte, final byte) {
+	if len(inter) == 0 && final == 'c' { // RIS
+		t.clear()
+	}
+}
+
+// reset returns the sequences to set the modes changed to their defaults.
+func (t *modeTracker) reset() string {
+	var set, reset []int
+	for m, on := range t.modes {
+		def := m == ansi.ShowCursor || m == ansi.AutoWrap // set by default
+		switch {
+		case on && !def:
+			reset = append(reset, int(m))
+		case !on && def:
+			set = append(set, int(m))
+		}
+	}
+	sort.Ints(set)
+	sort.Ints(reset)
+
+	s := ansi.ResetMode(toModes(reset)...) + ansi.SetMode(toModes(set)...)
+	if t.attrs {
+		s += ansi.CSI + "m"
+	}
+	return s
+}
+
+func toModes(a []int) []ansi.Mode {
+	modes := make([]ansi.Mode, len(a))
+	for i, m := range a {
+		modes[i] = ansi.Mode(m)
+	}
+	return modes
+}
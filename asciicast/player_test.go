@@ -0,0 +1,176 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kless/terminal/ansi"
+	"github.com/kless/terminal/keys"
+	"github.com/kless/terminal/vt"
+)
+
+var testRecording = &Recording{
+	Header: Header{Version: 2, Width: 20, Height: 5},
+	Events: []Event{
+		{1, OutputEvent, "one\r\n"},
+		{2, OutputEvent, "two\r\n"},
+		{2, MarkerEvent, "middle"},
+		{30, ResizeEvent, "30x6"},
+		{31, OutputEvent, "three\r\n"},
+		{32, OutputEvent, "\033[2Jfour"},
+	},
+}
+
+func TestPlay(t *testing.T) {
+	scr := vt.New(5, 20)
+	p := NewPlayer(testRecording)
+	p.Speed = 100
+	p.IdleTimeLimit = time.Second
+
+	start := time.Now()
+	if err := p.Play(scr, strings.NewReader(""), nil); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond || d > time.Second {
+		t.Errorf("played in %s, want 50ms", d)
+	}
+
+	if want := []float64{1, 2, 2, 3, 4, 5}; !reflect.DeepEqual(p.times, want) {
+		t.Errorf("times %v, want %v", p.times, want)
+	}
+	if scr.String() != "\n\n\nfour" {
+		t.Errorf("screen %q", scr.String())
+	}
+	if rows, cols := p.Size(); rows != 6 || cols != 30 {
+		t.Errorf("size %dx%d, want 30x6", cols, rows)
+	}
+}
+
+func TestPlayerKeys(t *testing.T) {
+	scr := vt.New(5, 20)
+	p := NewPlayer(testRecording)
+	p.IdleTimeLimit = 2 * time.Second
+	p.init(scr)
+
+	press := func(k keys.KeyEvent) {
+		if quit, err := p.key(k); err != nil || quit {
+			t.Fatalf("%s: quit %v, error %v", k, quit, err)
+		}
+	}
+
+	press(keys.KeyEvent{Rune: ' '})
+	if !p.paused || p.now() > 0.1 {
+		t.Fatalf("not paused at start: %v, %f", p.paused, p.now())
+	}
+
+	press(keys.KeyEvent{Rune: '.'})
+	if scr.String() != "one" || p.now() != 1 {
+		t.Errorf("next output: screen %q at %f", scr.String(), p.now())
+	}
+	press(keys.KeyEvent{Rune: ']'})
+	if scr.String() != "one\ntwo" || p.pos != 3 {
+		t.Errorf("next marker: screen %q at event %d", scr.String(), p.pos)
+	}
+
+	press(keys.KeyEvent{Key: keys.KeyRight})
+	if scr.String() != "\n\n\nfour" || p.now() != 7 {
+		t.Errorf("forward: screen %q at %f", scr.String(), p.now())
+	}
+	if rows, cols := p.Size(); rows != 6 || cols != 30 {
+		t.Errorf("forward: size %dx%d, want 30x6", cols, rows)
+	}
+
+	// Going back resets the screen.
+	press(keys.KeyEvent{Key: keys.KeyLeft})
+	if scr.String() != "one\ntwo" || p.now() != 2 {
+		t.Errorf("back: screen %q at %f", scr.String(), p.now())
+	}
+	if rows, cols := p.Size(); rows != 5 || cols != 20 {
+		t.Errorf("back: size %dx%d, want 20x5", cols, rows)
+	}
+	press(keys.KeyEvent{Key: keys.KeyLeft})
+	if scr.String() != "" || p.now() != 0 {
+		t.Errorf("back to start: screen %q at %f", scr.String(), p.now())
+	}
+
+	press(keys.KeyEvent{Rune: '+'})
+	if p.Speed != 2 {
+		t.Errorf("speed %f, want 2", p.Speed)
+	}
+
+	for _, k := range []keys.KeyEvent{{Rune: 'q'}, {Rune: 'c', Mod: keys.ModCtrl}} {
+		if quit, _ := p.key(k); !quit {
+			t.Errorf("%s: not quit", k)
+		}
+	}
+}
+
+func TestPlayResetModes(t *testing.T) {
+	rec := &Recording{
+		Header: Header{Version: 2, Width: 20, Height: 5},
+		Events: []Event{
+			{0, OutputEvent, "one\033[?1049h\033[?1000;2004h\033[?25l\033[1mtwo"},
+		},
+	}
+	scr := vt.New(5, 20)
+	p := NewPlayer(rec)
+
+	if err := p.Play(scr, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if scr.AltScreen() || !scr.CursorVisible() {
+		t.Errorf("alternate screen %v, cursor visible %v", scr.AltScreen(), scr.CursorVisible())
+	}
+	for _, m := range []ansi.Mode{ansi.MouseNormal, ansi.BracketedPaste} {
+		if scr.Mode(m) {
+			t.Errorf("mode %d not reset", m)
+		}
+	}
+	if scr.String() != "one" {
+		t.Errorf("screen %q", scr.String())
+	}
+	if want := "\033[?1000;1049;2004l\033[?25h\033[m"; p.modes.reset() != want {
+		t.Errorf("reset %q, want %q", p.modes.reset(), want)
+	}
+}
+
+// idleReader is a keys.TimeoutReader without input, which reports whether it is
+// being read.
+type idleReader struct {
+	reading int32
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	atomic.StoreInt32(&r.reading, 1)
+	select {} // without input
+}
+
+func (r *idleReader) ReadTimeout(p []byte, timeout time.Duration) (int, error) {
+	atomic.StoreInt32(&r.reading, 1)
+	defer atomic.StoreInt32(&r.reading, 0)
+	time.Sleep(timeout)
+	return 0, nil
+}
+
+func TestPlayStopReading(t *testing.T) {
+	p := NewPlayer(testRecording)
+	p.Speed = 100
+	p.IdleTimeLimit = time.Second
+
+	in := new(idleReader)
+	if err := p.Play(vt.New(5, 20), in, nil); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&in.reading) != 0 {
+		t.Error("a read is left pending after of playing")
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package asciicast
+
+import (
+	"context"
+	"io"
+
+	"github.com/kless/terminal"
+)
+
+// PlayTerminal replays the recording like Play, writing the output to w and
+// reading the keys from the terminal, which is put in raw mode until the end.
+//
+// The output is redrawn when the window size changes.
+func (p *Player) PlayTerminal(term *terminal.Terminal, w io.Writer) error {
+	if err := term.RawMode(); err != nil {
+		return err
+	}
+	defer term.Restore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sizes := term.NotifyResize(ctx)
+
+	resize := make(chan bool)
+	go func() {
+		for _ = range sizes {
+			select {
+			case resize <- true:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return p.Play(w, term, resize)
+}
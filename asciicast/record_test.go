@@ -0,0 +1,177 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package asciicast
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kless/terminal/expect"
+)
+
+// clock returns a function which returns a time advancing a second in each
+// call.
+func clock() func() time.Time {
+	t := time.Unix(1504467315, 0)
+	return func() time.Time {
+		t = t.Add(time.Second)
+		return t
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var buf, out bytes.Buffer
+
+	rec, err := newRecorder(&buf, &Header{Width: 80, Height: 24, Env: map[string]string{}}, clock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := rec.Output(&out)
+
+	// "é" split in two writes.
+	w.Write([]byte("h\xc3"))
+	w.Write([]byte("\xa9llo"))
+	rec.Resize(24, 80) // same size
+	rec.Resize(30, 100)
+	rec.Marker("end")
+	rec.Input(strings.NewReader("q")).Read(make([]byte, 4))
+	w.Write([]byte("\xe2\x82"))
+	rec.Close()
+	w.Write([]byte("lost"))
+
+	if out.String() != "h\xc3\xa9llo\xe2\x82lost" {
+		t.Errorf("output not written: %q", out.String())
+	}
+
+	want := `{"version":2,"width":80,"height":24,"timestamp":1504467316}
+[1.000000, "o", "h"]
+[2.000000, "o", "éllo"]
+[3.000000, "r", "100x30"]
+[4.000000, "m", "end"]
+[5.000000, "i", "q"]
+[6.000000, "o", "��"]
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// The test binary is run in a pseudo-terminal to record a command, when it is
+// set this variable to the file of the recording.
+const helperEnv = "ASCIICAST_TEST_HELPER"
+
+// TestHelperProcess is not a real test; it records a command in the process
+// started by TestRunCommand.
+func TestHelperProcess(t *testing.T) {
+	file := os.Getenv(helperEnv)
+	if file == "" {
+		return
+	}
+
+	err := func() error {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		rec, err := NewRecorder(f, &Header{Command: "sh"})
+		if err != nil {
+			return err
+		}
+		defer rec.Close()
+		rec.RecordInput = true
+
+		if err = rec.RunCommand(exec.Command("sh", "-c",
+			`stty size; read line; printf 'h\303\251llo %s\n' "$line"`)); err != nil {
+			return err
+		}
+
+		// The input typed after of the command is not read by the recorder.
+		fmt.Println("done")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		fmt.Printf("after %q\n", line)
+		return nil
+	}()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestRunCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asciicast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "rec.cast")
+
+	defer func(rows, cols int) {
+		expect.DefaultRows, expect.DefaultColumns = rows, cols
+	}(expect.DefaultRows, expect.DefaultColumns)
+	expect.DefaultRows, expect.DefaultColumns = 30, 100
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), helperEnv+"="+file)
+
+	e, err := expect.Start(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	if _, err = e.ExpectString("30 100", 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	e.SendLine("world")
+	if _, err = e.ExpectString("héllo world", 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	if _, err = e.ExpectString("done", 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	e.SendLine("next")
+	if _, err = e.ExpectString(`after "next\n"`, 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	if err = e.Wait(); err != nil {
+		t.Fatalf("%s; output %q", err, e.Transcript())
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := string(data)
+
+	for _, s := range []string{
+		`"width":100,"height":30,`,
+		`"command":"sh"`,
+		`"o", "30 100`,
+		`"i", "w`,
+		`héllo world`,
+	} {
+		if !strings.Contains(rec, s) {
+			t.Errorf("recording without %q:\n%s", s, rec)
+		}
+	}
+}
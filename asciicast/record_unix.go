@@ -0,0 +1,297 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package asciicast
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kless/terminal"
+)
+
+// Size of the terminal by default, when it can not be got.
+const (
+	DefaultRows    = 24
+	DefaultColumns = 80
+)
+
+// A Recorder records a terminal session, either the output of the own process
+// or a command attached to a pseudo-terminal:
+//
+//   rec, err := asciicast.NewRecorder(file, &asciicast.Header{Command: "make"})
+//   if err != nil {
+//   	return err
+//   }
+//   defer rec.Close()
+//
+//   cmd := exec.Command("make")
+//   err = rec.RunCommand(cmd)
+//
+// The data is split in events at the boundaries of the UTF-8 characters, since
+// the JSON strings have to be valid UTF-8.
+type Recorder struct {
+	// RecordInput sets whether RunCommand records the input typed.
+	RecordInput bool
+
+	w     *Writer
+	start time.Time
+	now   func() time.Time
+
+	mu            sync.Mutex
+	rows, columns int
+	streams       []*stream
+	closed        bool
+
+	ctx    context.Context // done at closing
+	cancel context.CancelFunc
+}
+
+// NewRecorder returns a recorder which writes to w, and writes the header.
+//
+// The fields of the header which are zero are set: the size to the one of the
+// terminal of standard output, or DefaultRows and DefaultColumns if it is not a
+// terminal; the timestamp to the actual time; and the environment to the
+// variables TERM and SHELL.
+func NewRecorder(w io.Writer, h *Header) (*Recorder, error) {
+	return newRecorder(w, h, time.Now)
+}
+
+func newRecorder(w io.Writer, h *Header, now func() time.Time) (*Recorder, error) {
+	start := now()
+
+	if h.Width == 0 || h.Height == 0 {
+		h.Height, h.Width = DefaultRows, DefaultColumns
+
+		if term, err := terminal.New(syscall.Stdout); err == nil {
+			if rows, cols, err := term.GetSize(); err == nil && rows != 0 && cols != 0 {
+				h.Height, h.Width = rows, cols
+			}
+		}
+	}
+	if h.Timestamp == 0 {
+		h.Timestamp = start.Unix()
+	}
+	if h.Env == nil {
+		h.Env = make(map[string]string)
+		for _, k := range []string{"TERM", "SHELL"} {
+			if v := os.Getenv(k); v != "" {
+				h.Env[k] = v
+			}
+		}
+	}
+
+	cw, err := NewWriter(w, h)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Recorder{
+		w:       cw,
+		start:   start,
+		now:     now,
+		rows:    h.Height,
+		columns: h.Width,
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// event writes an event at the actual time. The events after of Close are
+// discarded.
+func (r *Recorder) event(typ EventType, data string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	return r.w.WriteEvent(Event{r.now().Sub(r.start).Seconds(), typ, data})
+}
+
+// Output returns a writer which writes to w, recording the data written as
+// output.
+func (r *Recorder) Output(w io.Writer) io.Writer {
+	return &outputWriter{w, r.newStream(OutputEvent)}
+}
+
+// Input returns a reader which reads from rd, recording the data read as
+// input.
+func (r *Recorder) Input(rd io.Reader) io.Reader {
+	return &inputReader{rd, r.newStream(InputEvent)}
+}
+
+// Resize records a change of the size of the terminal.
+func (r *Recorder) Resize(rows, columns int) error {
+	r.mu.Lock()
+	if rows == r.rows && columns == r.columns {
+		r.mu.Unlock()
+		return nil
+	}
+	r.rows, r.columns = rows, columns
+	r.mu.Unlock()
+
+	return r.event(ResizeEvent, ResizeData(rows, columns))
+}
+
+// Marker records a marker, to navigate through the recording.
+func (r *Recorder) Marker(label string) error {
+	return r.event(MarkerEvent, label)
+}
+
+// WatchSize records the changes of the size of the terminal, until the
+// recorder is closed.
+func (r *Recorder) WatchSize(term *terminal.Terminal) {
+	sizes := term.NotifyResize(r.ctx)
+
+	go func() {
+		for size := range sizes {
+			r.Resize(size.Rows, size.Columns)
+		}
+	}()
+}
+
+// RunCommand runs the command attached to a new pseudo-terminal, like
+// terminal.RunCommand, recording its output, the changes of the window size,
+// and the input if RecordInput is set.
+func (r *Recorder) RunCommand(cmd *exec.Cmd) error {
+	relay := terminal.Relay{
+		Output: r.Output(os.Stdout),
+		Resize: func(rows, columns int) { r.Resize(rows, columns) },
+	}
+	if r.RecordInput {
+		relay.Input = r.newStream(InputEvent)
+	}
+	return terminal.RunCommandRelay(cmd, relay)
+}
+
+// Close records the data pending, and stops the recording. It does not close
+// the underlying writer.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	streams := r.streams
+	r.mu.Unlock()
+
+	var err error
+	for _, s := range streams {
+		if e := s.flush(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	r.mu.Lock()
+	r.closed = true
+	r.cancel()
+	r.mu.Unlock()
+	return err
+}
+
+// == Streams
+//
+
+// stream records the data of a type of event, keeping the bytes of an
+// incomplete UTF-8 character until the next data.
+type stream struct {
+	r       *Recorder
+	typ     EventType
+	mu      sync.Mutex
+	pending []byte
+}
+
+func (r *Recorder) newStream(typ EventType) *stream {
+	s := &stream{r: r, typ: typ}
+
+	r.mu.Lock()
+	r.streams = append(r.streams, s)
+	r.mu.Unlock()
+	return s
+}
+
+func (s *stream) record(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.pending, p...)
+	n := completeUTF8(data)
+	s.pending = append([]byte(nil), data[n:]...)
+
+	if n == 0 {
+		return nil
+	}
+	return s.r.event(s.typ, string(data[:n]))
+}
+
+// Write records the data, to be used as a copy of the data read or written.
+func (s *stream) Write(p []byte) (int, error) {
+	if err := s.record(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *stream) flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+	data := string(s.pending)
+	s.pending = nil
+	return s.r.event(s.typ, data)
+}
+
+// completeUTF8 returns the length of data without an incomplete UTF-8
+// character at the end.
+func completeUTF8(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
+
+type outputWriter struct {
+	w io.Writer
+	s *stream
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.s.record(p[:n])
+	}
+	return n, err
+}
+
+type inputReader struct {
+	rd io.Reader
+	s  *stream
+}
+
+func (r *inputReader) Read(p []byte) (int, error) {
+	n, err := r.rd.Read(p)
+	if n > 0 {
+		r.s.record(p[:n])
+	}
+	return n, err
+}
@@ -0,0 +1,171 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import "testing"
+
+func TestAttributes(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	attr, err := pty.Attributes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attr.SetLflag(ECHO, false)
+	attr.SetIflag(ICRNL, true)
+	if err = attr.SetCc(VINTR, 'x'&0x1f); err != nil {
+		t.Fatal(err)
+	}
+	if err = attr.SetCharSize(7); err != nil {
+		t.Fatal(err)
+	}
+	// The character size is checked before of setting the attributes, since a
+	// pseudo-terminal does not keep it.
+	if n := attr.CharSize(); n != 7 || !attr.Cflag(CS7) || attr.Cflag(CS8) {
+		t.Errorf("expected character size of 7 bits, got %d", n)
+	}
+	if err = attr.SetSpeed(9600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, when := range []When{TCSANOW, TCSADRAIN, TCSAFLUSH} {
+		if err = pty.SetAttributes(attr, when); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if attr, err = pty.Attributes(); err != nil {
+		t.Fatal(err)
+	}
+	if attr.Lflag(ECHO) {
+		t.Error("expected echo off")
+	}
+	if !attr.Iflag(ICRNL) {
+		t.Error("expected ICRNL on")
+	}
+	if c := attr.Cc(VINTR); c != 0x18 {
+		t.Errorf("expected VINTR set to ^X, got %#x", c)
+	}
+
+	if in, out := attr.InputSpeed(), attr.OutputSpeed(); in != 9600 || out != 9600 {
+		t.Errorf("expected speed 9600, got %d/%d", in, out)
+	}
+
+	if err = pty.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if attr, _ = pty.Attributes(); !attr.Lflag(ECHO) {
+		t.Error("expected to restore echo")
+	}
+}
+
+func TestAttributesFlags(t *testing.T) {
+	var attr Attributes
+
+	attr.SetCflag(CS8, true)
+	if !attr.Cflag(CS8) || attr.Cflag(CS7) || attr.Cflag(CS6) || attr.CharSize() != 8 {
+		t.Errorf("CS8: expected only the size of 8 bits, got %d", attr.CharSize())
+	}
+	attr.SetCflag(CS7, true)
+	if !attr.Cflag(CS7) || attr.Cflag(CS8) || attr.CharSize() != 7 {
+		t.Errorf("CS7: expected only the size of 7 bits, got %d", attr.CharSize())
+	}
+	attr.SetCflag(CS6, false) // not set
+	if attr.CharSize() != 7 {
+		t.Errorf("-CS6: expected size of 7 bits, got %d", attr.CharSize())
+	}
+	attr.SetCflag(CS7, false)
+	if attr.CharSize() != 5 {
+		t.Errorf("-CS7: expected size of 5 bits, got %d", attr.CharSize())
+	}
+
+	attr.SetOflag(CR3, true)
+	attr.SetOflag(OPOST, true)
+	if attr.Oflag(CR1) || attr.Oflag(CR2) || !attr.Oflag(CR3) || !attr.Oflag(OPOST) {
+		t.Error("CR3: expected only the delay CR3")
+	}
+
+	attr.SetIflag(IXON|IXOFF, true)
+	attr.SetIflag(IXON|IXOFF, false)
+	if attr.Iflag(IXON) || attr.Iflag(IXOFF) {
+		t.Error("expected to clear several single bits")
+	}
+
+	if err := attr.SetCc(len(attr.wrap.Cc), 1); err == nil {
+		t.Error("expected error at setting a control character out of range")
+	}
+	if err := attr.SetCc(-1, 1); err == nil {
+		t.Error("expected error at setting a negative index")
+	}
+	if c := attr.Cc(len(attr.wrap.Cc)); c != 0 {
+		t.Errorf("expected zero out of range, got %#x", c)
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	for _, baud := range []int{9600, 460800, 4000000, 12345} {
+		attr, err := pty.Attributes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attr.SetSpeed(baud) == nil {
+			err = pty.SetAttributes(attr, TCSANOW)
+		} else {
+			err = setCustomSpeed(pty.Fd(), baud)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in, out, err := pty.Speed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if in != baud || out != baud {
+			t.Errorf("expected speed %d, got %d/%d", baud, in, out)
+		}
+	}
+
+	attr, err := pty.Attributes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = attr.SetInputSpeed(9600); err != nil {
+		t.Fatal(err)
+	}
+	if err = attr.SetOutputSpeed(38400); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.SetAttributes(attr, TCSANOW); err != nil {
+		t.Fatal(err)
+	}
+
+	if attr, err = pty.Attributes(); err != nil {
+		t.Fatal(err)
+	}
+	if in, out := attr.InputSpeed(), attr.OutputSpeed(); in != 9600 || out != 38400 {
+		t.Errorf("expected speed 9600/38400 in the attributes, got %d/%d", in, out)
+	}
+	if in, out, err := pty.Speed(); err != nil {
+		t.Fatal(err)
+	} else if in != 9600 || out != 38400 {
+		t.Errorf("expected speed 9600/38400, got %d/%d", in, out)
+	}
+}
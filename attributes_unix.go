@@ -0,0 +1,235 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import "fmt"
+
+// When specifies when the changes of attributes take effect.
+type When uint
+
+const (
+	TCSANOW   When = _TCSANOW   // Immediately.
+	TCSADRAIN When = _TCSADRAIN // After all output has been transmitted.
+	TCSAFLUSH When = _TCSAFLUSH // Like TCSADRAIN, discarding the pending input.
+)
+
+// Attributes represents the attributes of a terminal, which are handled through
+// the modes and the control characters.
+//
+// The flags for each mode are the constants named as in termios(3), i.e. the
+// bit ECHO of the local modes is got through Lflag(ECHO). The values of the
+// fields of several bits are compared against the whole field, i.e. Cflag(CS7)
+// reports whether the character size is of 7 bits, and SetCflag(CS7, true)
+// replaces the character size.
+type Attributes struct {
+	wrap termios
+}
+
+// Attributes returns the actual attributes of the terminal.
+func (t *Terminal) Attributes() (Attributes, error) {
+	var attr Attributes
+
+	if err := tcgetattr(t.fd, &attr.wrap); err != nil {
+		return attr, fmt.Errorf("terminal: could not get attributes: %s", err)
+	}
+	return attr, nil
+}
+
+// SetAttributes sets the attributes of the terminal.
+func (t *Terminal) SetAttributes(attr Attributes, when When) error {
+	if err := tcsetattr(t.fd, uint(when), &attr.wrap); err != nil {
+		return fmt.Errorf("terminal: could not set attributes: %s", err)
+	}
+
+	t.lastState = attr.wrap
+	t.mod |= otherMode
+	return nil
+}
+
+// == Modes
+//
+
+// Iflag reports whether the flag is set in the input modes.
+func (a *Attributes) Iflag(flag uint) bool { return hasFlag(a.wrap.Iflag, flag, nil) }
+
+// Oflag reports whether the flag is set in the output modes.
+func (a *Attributes) Oflag(flag uint) bool { return hasFlag(a.wrap.Oflag, flag, oflagMasks) }
+
+// Cflag reports whether the flag is set in the control modes.
+func (a *Attributes) Cflag(flag uint) bool { return hasFlag(a.wrap.Cflag, flag, cflagMasks) }
+
+// Lflag reports whether the flag is set in the local modes.
+func (a *Attributes) Lflag(flag uint) bool { return hasFlag(a.wrap.Lflag, flag, nil) }
+
+// SetIflag sets or clears the flag in the input modes.
+func (a *Attributes) SetIflag(flag uint, on bool) { setFlag(&a.wrap.Iflag, flag, on, nil) }
+
+// SetOflag sets or clears the flag in the output modes.
+func (a *Attributes) SetOflag(flag uint, on bool) { setFlag(&a.wrap.Oflag, flag, on, oflagMasks) }
+
+// SetCflag sets or clears the flag in the control modes.
+func (a *Attributes) SetCflag(flag uint, on bool) { setFlag(&a.wrap.Cflag, flag, on, cflagMasks) }
+
+// SetLflag sets or clears the flag in the local modes.
+func (a *Attributes) SetLflag(flag uint, on bool) { setFlag(&a.wrap.Lflag, flag, on, nil) }
+
+// CharSize returns the number of bits per character, set in the control modes
+// through CSIZE.
+func (a *Attributes) CharSize() int {
+	switch a.wrap.Cflag & CSIZE {
+	case CS5:
+		return 5
+	case CS6:
+		return 6
+	case CS7:
+		return 7
+	}
+	return 8
+}
+
+// SetCharSize sets the number of bits per character, from 5 to 8.
+func (a *Attributes) SetCharSize(bits int) error {
+	var size tcflag
+
+	switch bits {
+	case 5:
+		size = CS5
+	case 6:
+		size = CS6
+	case 7:
+		size = CS7
+	case 8:
+		size = CS8
+	default:
+		return fmt.Errorf("terminal: invalid character size: %d", bits)
+	}
+
+	a.wrap.Cflag &^= CSIZE
+	a.wrap.Cflag |= size
+	return nil
+}
+
+// MakeRaw sets the modes used in RawMode.
+func (a *Attributes) MakeRaw() {
+	makeRaw(&a.wrap)
+}
+
+// == Control characters
+//
+
+// Cc returns the control character at index, which is one of the constants
+// VINTR, VQUIT, VERASE, VKILL, VEOF, VMIN, VTIME, etc.
+// It is zero if the index is out of the control characters.
+func (a *Attributes) Cc(index int) byte {
+	if index < 0 || index >= len(a.wrap.Cc) {
+		return 0
+	}
+	return a.wrap.Cc[index]
+}
+
+// SetCc sets the control character at index.
+func (a *Attributes) SetCc(index int, c byte) error {
+	if index < 0 || index >= len(a.wrap.Cc) {
+		return fmt.Errorf("terminal: invalid index of control character: %d", index)
+	}
+	a.wrap.Cc[index] = c
+	return nil
+}
+
+// == Speed
+//
+
+// Speed returns the input and output baud rates of the terminal, including the
+// arbitrary ones set through OpenSerial, in Linux.
+func (t *Terminal) Speed() (in, out int, err error) {
+	if in, out, err = readSpeed(t.fd); err != nil {
+		return 0, 0, fmt.Errorf("terminal: could not get speed: %s", err)
+	}
+	return in, out, nil
+}
+
+// InputSpeed returns the input baud rate.
+// It is -1 if the baud rate is not a standard one; see Terminal.Speed.
+func (a *Attributes) InputSpeed() int {
+	in, _ := getSpeed(&a.wrap)
+	return in
+}
+
+// OutputSpeed returns the output baud rate.
+// It is -1 if the baud rate is not a standard one; see Terminal.Speed.
+func (a *Attributes) OutputSpeed() int {
+	_, out := getSpeed(&a.wrap)
+	return out
+}
+
+// SetSpeed sets both input and output baud rates.
+// The arbitrary baud rates have to be set through OpenSerial, in Linux.
+func (a *Attributes) SetSpeed(baud int) error {
+	if !setSpeed(&a.wrap, baud) {
+		return fmt.Errorf("terminal: not a standard baud rate: %d", baud)
+	}
+	return nil
+}
+
+// SetInputSpeed sets the input baud rate. In Linux, it has to be a standard
+// one, and the zero sets it like the output one.
+func (a *Attributes) SetInputSpeed(baud int) error {
+	if !setInputSpeed(&a.wrap, baud) {
+		return fmt.Errorf("terminal: not a standard baud rate: %d", baud)
+	}
+	return nil
+}
+
+// SetOutputSpeed sets the output baud rate. In Linux, it has to be a standard
+// one.
+func (a *Attributes) SetOutputSpeed(baud int) error {
+	if !setOutputSpeed(&a.wrap, baud) {
+		return fmt.Errorf("terminal: not a standard baud rate: %d", baud)
+	}
+	return nil
+}
+
+// == Utility
+//
+
+// oflagMasks are the masks of the fields of several bits in the output modes.
+var oflagMasks = []tcflag{CR1 | CR2 | CR3, TAB1 | TAB2 | XTABS}
+
+// fieldMask returns the mask of the field of several bits whose value is flag,
+// or flag if it is not a value of those fields.
+func fieldMask(flag tcflag, masks []tcflag) tcflag {
+	if flag == 0 {
+		return 0
+	}
+	for _, m := range masks {
+		if flag&^m == 0 {
+			return m
+		}
+	}
+	return flag
+}
+
+func hasFlag(field tcflag, flag uint, masks []tcflag) bool {
+	f := tcflag(flag)
+	return field&fieldMask(f, masks) == f
+}
+
+func setFlag(field *tcflag, flag uint, on bool, masks []tcflag) {
+	f := tcflag(flag)
+	m := fieldMask(f, masks)
+
+	switch {
+	case on:
+		*field = *field&^m | f
+	case m == f:
+		*field &^= f
+	case *field&m == f: // the value is cleared leaving the field to zero
+		*field &^= m
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+/*
+Command gostty prints or changes the settings of a terminal, like stty.
+
+Usage:
+
+  gostty [-F device | -fd N] [-a | -g | setting...]
+
+By default, it is used the terminal of the standard input. Without settings, it
+prints all the settings, like "-a". The settings are:
+
+  [-]mode      Set or clear a mode, i.e. "echo" or "-echo".
+  cs5..cs8     Set the character size.
+  char value   Set a control character, i.e. "intr ^C"; "undef" disables it.
+  min N        Set the minimum number of characters for a read, in raw mode.
+  time N       Set the timeout in tenths of second for a read, in raw mode.
+  raw          Set the raw mode.
+  -raw, cooked Set the mode by default, with line editing and echo.
+  sane         Set sane values for all settings.
+  rows N       Set the number of rows of the window.
+  cols N       Set the number of columns of the window; also "columns".
+  size         Print the number of rows and columns.
+  speed        Print the baud rate.
+  [speed] N    Set the baud rate.
+  STATE        Restore the settings from the output of "-g".
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/kless/terminal"
+)
+
+// options are the options given in the command line.
+type options struct {
+	device string // open and use the device instead of standard input
+	fd     int    // use the file descriptor
+	all    bool   // print all settings in human-readable form
+	save   bool   // print all settings in a form readable by gostty
+}
+
+// cookedSettings are the settings to exit from the raw mode.
+var cookedSettings = []string{
+	"brkint", "ignpar", "istrip", "icrnl", "ixon", "opost", "isig", "icanon",
+	"iexten", "echo",
+}
+
+// saneSettings are the settings by default of a terminal.
+var saneSettings = []string{
+	"cread", "-ignbrk", "brkint", "-inlcr", "-igncr", "icrnl", "-ixoff",
+	"-ixany", "imaxbel", "opost", "-ocrnl", "onlcr", "-onocr", "-onlret",
+	"isig", "icanon", "iexten", "echo", "echoe", "echok", "-echonl", "-noflsh",
+	"-tostop", "-echoprt", "echoctl", "echoke",
+}
+
+// saneChars are the control characters by default.
+var saneChars = [][2]string{
+	{"intr", "^C"}, {"quit", "^\\"}, {"erase", "^?"}, {"kill", "^U"},
+	{"eof", "^D"}, {"eol", "undef"}, {"eol2", "undef"}, {"start", "^Q"},
+	{"stop", "^S"}, {"susp", "^Z"}, {"rprnt", "^R"}, {"werase", "^W"},
+	{"lnext", "^V"}, {"discard", "^O"}, {"min", "1"}, {"time", "0"},
+}
+
+// controlChars are the names of control characters.
+var controlChars = map[string]bool{
+	"intr": true, "quit": true, "erase": true, "kill": true, "eof": true,
+	"eol": true, "eol2": true, "start": true, "stop": true, "susp": true,
+	"rprnt": true, "werase": true, "lnext": true, "discard": true,
+	"min": true, "time": true,
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: gostty [-F device | -fd N] [-a | -g | setting...]
+
+  -F device  open and use the device instead of standard input
+  -fd N      use the file descriptor
+  -a         print all settings in human-readable form
+  -g         print all settings in a form readable by gostty
+`)
+	os.Exit(2)
+}
+
+// parseArgs returns the options and the settings given in args. The flag
+// package is not used since the settings can start with "-", like "-echo", so
+// every argument which is not an option is a setting; and "--" ends the
+// options.
+func parseArgs(args []string) (opt options, settings []string, err error) {
+	opt.fd = syscall.Stdin
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "-a":
+			opt.all = true
+		case "-g":
+			opt.save = true
+		case "-F", "-fd":
+			if i+1 == len(args) {
+				return opt, nil, fmt.Errorf("missing argument to %q", arg)
+			}
+			i++
+			if arg == "-F" {
+				opt.device = args[i]
+			} else if opt.fd, err = strconv.Atoi(args[i]); err != nil || opt.fd < 0 {
+				return opt, nil, fmt.Errorf("invalid file descriptor: %q", args[i])
+			}
+		case "-h", "-help", "--help":
+			usage()
+		case "--":
+			return opt, append(settings, args[i+1:]...), nil
+		default:
+			settings = append(settings, arg)
+		}
+	}
+	return opt, settings, nil
+}
+
+func main() {
+	opt, settings, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fatal(err)
+	}
+
+	if opt.all && opt.save {
+		fatal(errors.New("the options -a and -g are mutually exclusive"))
+	}
+	if (opt.all || opt.save) && len(settings) != 0 {
+		fatal(errors.New("the settings can not be changed with -a or -g"))
+	}
+
+	fd := opt.fd
+	if opt.device != "" {
+		f, err := os.OpenFile(opt.device, os.O_RDONLY|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			fatal(err)
+		}
+		// The file is kept until the end, to not close the descriptor at
+		// collecting it.
+		defer f.Close()
+		fd = int(f.Fd()) // blocking mode
+	}
+
+	term, err := terminal.New(fd)
+	if err != nil {
+		fatal(fmt.Errorf("not a terminal: %s", err))
+	}
+
+	switch {
+	case opt.save:
+		state, err := term.CurrentState()
+		if err != nil {
+			fatal(err)
+		}
+		text, _ := state.MarshalText()
+		fmt.Printf("%s\n", text)
+		return
+	case opt.all || len(settings) == 0:
+		printAll(term)
+		return
+	}
+
+	if err = run(term, settings); err != nil {
+		fatal(err)
+	}
+}
+
+// run applies the settings given in args.
+func run(term *terminal.Terminal, args []string) error {
+	attr, err := term.Attributes()
+	if err != nil {
+		return err
+	}
+	changed := false
+	row, col := -1, -1
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// value returns the argument of a setting.
+		value := func() (string, error) {
+			if i+1 == len(args) {
+				return "", fmt.Errorf("missing argument to %q", arg)
+			}
+			i++
+			return args[i], nil
+		}
+		number := func() (int, error) {
+			v, err := value()
+			if err != nil {
+				return 0, err
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid number for %q: %q", arg, v)
+			}
+			return n, nil
+		}
+
+		switch {
+		case strings.Contains(arg, ":"):
+			state, err := term.CurrentState()
+			if err != nil {
+				return err
+			}
+			if err = state.UnmarshalText([]byte(arg)); err != nil {
+				return err
+			}
+			attr = state.Attributes()
+			changed = true
+
+		case arg == "raw":
+			attr.MakeRaw()
+			changed = true
+		case arg == "-raw" || arg == "cooked":
+			for _, s := range cookedSettings {
+				attr.Set(s)
+			}
+			attr.SetChar("eof", "^D")
+			attr.SetChar("eol", "undef")
+			changed = true
+		case arg == "sane":
+			for _, s := range saneSettings {
+				attr.Set(s)
+			}
+			for _, c := range saneChars {
+				attr.SetChar(c[0], c[1])
+			}
+			changed = true
+
+		case arg == "rows":
+			if row, err = number(); err != nil {
+				return err
+			}
+		case arg == "cols" || arg == "columns":
+			if col, err = number(); err != nil {
+				return err
+			}
+		case arg == "size":
+			r, c, err := term.GetSize()
+			if err != nil {
+				return err
+			}
+			fmt.Println(r, c)
+
+		case arg == "speed" && i+1 == len(args):
+			_, out, err := term.Speed()
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+		case arg == "speed" || arg != "" && arg[0] >= '0' && arg[0] <= '9':
+			if arg == "speed" {
+				i++
+				arg = args[i]
+			}
+			baud, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("invalid speed: %q", arg)
+			}
+			if err = attr.SetSpeed(baud); err != nil {
+				return err
+			}
+			changed = true
+
+		case controlChars[arg]:
+			v, err := value()
+			if err != nil {
+				return err
+			}
+			if err = attr.SetChar(arg, v); err != nil {
+				return err
+			}
+			changed = true
+
+		default:
+			if err = attr.Set(arg); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+
+	if changed {
+		if err = term.SetAttributes(attr, terminal.TCSADRAIN); err != nil {
+			return err
+		}
+	}
+
+	if row != -1 || col != -1 {
+		r, c, err := term.GetSize()
+		if err != nil {
+			return err
+		}
+		x, y, err := term.GetSizePixels()
+		if err != nil {
+			return err
+		}
+		if row == -1 {
+			row = r
+		}
+		if col == -1 {
+			col = c
+		}
+		return term.SetSize(row, col, x, y)
+	}
+	return nil
+}
+
+// printAll prints all settings in human-readable form.
+func printAll(term *terminal.Terminal) {
+	state, err := term.CurrentState()
+	if err != nil {
+		fatal(err)
+	}
+	if row, col, err := term.GetSize(); err == nil {
+		fmt.Printf("rows %d; columns %d;\n", row, col)
+	}
+	if attr := state.Attributes(); attr.OutputSpeed() == -1 { // arbitrary baud rate
+		if _, out, err := term.Speed(); err == nil {
+			fmt.Printf("speed %d baud;\n", out)
+		}
+	}
+	fmt.Print(state)
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "gostty: %s\n", err)
+	os.Exit(1)
+}
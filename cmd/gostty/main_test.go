@@ -0,0 +1,82 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/kless/terminal"
+)
+
+// The test binary is run as gostty with the arguments set in this variable,
+// separated by spaces.
+const helperEnv = "GOSTTY_TEST_HELPER"
+
+// TestHelperProcess is not a real test; it runs gostty in the process started
+// by runGostty.
+func TestHelperProcess(t *testing.T) {
+	args := os.Getenv(helperEnv)
+	if args == "" {
+		return
+	}
+	os.Args = append([]string{"gostty"}, strings.Fields(args)...)
+	main()
+	os.Exit(0)
+}
+
+// runGostty runs gostty with the arguments on the terminal.
+func runGostty(t *testing.T, pty *terminal.PTY, args ...string) {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), helperEnv+"="+strings.Join(args, " "))
+	cmd.Stdin = pty.Slave
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("gostty %s: %s; output %q", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	pty, err := terminal.OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	// The settings starting with "-" are not parsed as options.
+	runGostty(t, pty, "-echo")
+	attr, err := pty.Attributes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attr.Lflag(terminal.ECHO) {
+		t.Error("gostty -echo: expected echo to be off")
+	}
+	if !attr.Lflag(terminal.ICANON) || !attr.Lflag(terminal.ISIG) {
+		t.Error("gostty -echo: expected icanon and isig to be kept")
+	}
+
+	runGostty(t, pty, "-icanon", "-isig")
+	if attr, err = pty.Attributes(); err != nil {
+		t.Fatal(err)
+	}
+	if attr.Lflag(terminal.ICANON) || attr.Lflag(terminal.ISIG) {
+		t.Error("gostty -icanon -isig: expected icanon and isig to be off")
+	}
+
+	runGostty(t, pty, "-fd", "0", "--", "echo")
+	if attr, err = pty.Attributes(); err != nil {
+		t.Fatal(err)
+	}
+	if !attr.Lflag(terminal.ECHO) {
+		t.Error("gostty -fd 0 -- echo: expected echo to be on")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+/*
+Command termplay replays a terminal session recorded in format asciicast v2,
+or by the command script with a timing file.
+
+Usage:
+
+  termplay [-speed N] [-idle SECONDS] file.cast
+  termplay [-speed N] [-idle SECONDS] -t timing typescript
+
+The replay is controlled with the keys:
+
+  Space        pause or resume
+  .            while paused, go to the next output
+  ]            go to the next marker
+  Left, Right  go back or forward 5 seconds
+  +, -         double or halve the speed
+  q, Ctrl+C    quit
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/kless/terminal"
+	"github.com/kless/terminal/asciicast"
+)
+
+var (
+	fSpeed  = flag.Float64("speed", 1, "multiplier of the speed")
+	fIdle   = flag.Float64("idle", 0, "maximum time in seconds between events")
+	fTiming = flag.String("t", "", "timing file of a typescript of script")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: termplay [-speed N] [-idle SECONDS] [-t timing] file\n\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+	}
+
+	rec, err := load(flag.Arg(0), *fTiming)
+	if err != nil {
+		fatal(err)
+	}
+
+	term, err := terminal.New(syscall.Stdin)
+	if err != nil {
+		fatal(fmt.Errorf("not a terminal: %s", err))
+	}
+
+	if row, col, err := term.GetSize(); err == nil && row != 0 &&
+		(row < rec.Header.Height || col < rec.Header.Width) {
+		fmt.Fprintf(os.Stderr, "termplay: the terminal (%dx%d) is smaller than the recording (%dx%d)\n",
+			col, row, rec.Header.Width, rec.Header.Height)
+		time.Sleep(2 * time.Second)
+	}
+
+	p := asciicast.NewPlayer(rec)
+	p.Speed = *fSpeed
+	p.IdleTimeLimit = time.Duration(*fIdle * float64(time.Second))
+
+	if err = p.PlayTerminal(term, os.Stdout); err != nil {
+		fatal(err)
+	}
+}
+
+// load loads the recording, in format asciicast or script if it is set the
+// timing file.
+func load(name, timing string) (*asciicast.Recording, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if timing == "" {
+		return asciicast.Decode(f)
+	}
+
+	ft, err := os.Open(timing)
+	if err != nil {
+		return nil, err
+	}
+	defer ft.Close()
+
+	return asciicast.DecodeScript(f, ft)
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "termplay: %s\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,48 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package terminal
+
+import (
+	"fmt"
+	"math"
+)
+
+// A Color represents a color in RGB, with 16 bits per channel like it is
+// reported by the terminal.
+type Color struct {
+	R, G, B uint16
+}
+
+// Luminance returns the relative luminance of the color, from 0 for black to 1
+// for white, as defined for sRGB.
+func (c Color) Luminance() float64 {
+	linear := func(v uint16) float64 {
+		x := float64(v) / 0xffff
+		if x <= 0.03928 {
+			return x / 12.92
+		}
+		return math.Pow((x+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// String returns the color in the format used by X11, "rgb:RRRR/GGGG/BBBB".
+func (c Color) String() string {
+	return fmt.Sprintf("rgb:%04x/%04x/%04x", c.R, c.G, c.B)
+}
+
+// ANSIColors are the 16 colors of the palette, as defined by default in xterm.
+var ANSIColors = [16]Color{
+	{0x0000, 0x0000, 0x0000}, {0xcdcd, 0x0000, 0x0000},
+	{0x0000, 0xcdcd, 0x0000}, {0xcdcd, 0xcdcd, 0x0000},
+	{0x0000, 0x0000, 0xeeee}, {0xcdcd, 0x0000, 0xcdcd},
+	{0x0000, 0xcdcd, 0xcdcd}, {0xe5e5, 0xe5e5, 0xe5e5},
+	{0x7f7f, 0x7f7f, 0x7f7f}, {0xffff, 0x0000, 0x0000},
+	{0x0000, 0xffff, 0x0000}, {0xffff, 0xffff, 0x0000},
+	{0x5c5c, 0x5c5c, 0xffff}, {0xffff, 0x0000, 0xffff},
+	{0x0000, 0xffff, 0xffff}, {0xffff, 0xffff, 0xffff},
+}
@@ -0,0 +1,110 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDefaultColors(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	go fakeTerminal(pty, map[string]string{
+		"\033]10;?\033\\": "\033]10;rgb:ffff/ffff/ffff\033\\",
+		"\033]11;?\033\\": "\033]11;rgb:1e/1e/2e\a",
+		"\033[c":          "\033[?62;22c",
+	})
+
+	c, err := pty.DefaultColors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Foreground != (Color{0xffff, 0xffff, 0xffff}) || c.Background != (Color{0x1e1e, 0x1e1e, 0x2e2e}) {
+		t.Errorf("unexpected colors: %v, %v", c.Foreground, c.Background)
+	}
+	if !c.Dark || c.Luminance > 0.02 {
+		t.Errorf("expected dark background, got luminance %f", c.Luminance)
+	}
+}
+
+func TestDefaultColorsEnv(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	go fakeTerminal(pty, map[string]string{"\033[c": "\033[?1;2c"})
+
+	defer os.Setenv("COLORFGBG", os.Getenv("COLORFGBG"))
+	os.Setenv("COLORFGBG", "0;default;15")
+
+	start := time.Now()
+	c, err := pty.DefaultColors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) >= QueryTimeout {
+		t.Error("expected to get the reply to DA1 before of the timeout")
+	}
+	if c.Dark || c.Luminance != 1 || c.Foreground != (Color{}) {
+		t.Errorf("expected light background, got %+v", c)
+	}
+
+	os.Setenv("COLORFGBG", "")
+	if _, err = pty.DefaultColors(); err != ErrNoReply {
+		t.Errorf("expected ErrNoReply, got %v", err)
+	}
+}
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		in  string
+		out Color
+	}{
+		{"\033]11;rgb:ffff/8000/0000\033\\", Color{0xffff, 0x8000, 0}},
+		{"\033]11;rgb:f/8/0\a", Color{0xffff, 0x8888, 0}},
+		{"\033]11;rgb:fff/800/000\a", Color{0xffff, 0x8007, 0}},
+	}
+	for _, tt := range tests {
+		if c, ok := oscColor([]byte(tt.in), "11"); !ok || c != tt.out {
+			t.Errorf("oscColor(%q) = %v, %v; want %v", tt.in, c, ok, tt.out)
+		}
+	}
+	if _, ok := oscColor([]byte("\033]11;rgb:ff/ff\a"), "11"); ok {
+		t.Error("expected invalid color")
+	}
+
+
+	envTests := []struct {
+		in   string
+		dark bool
+	}{
+		{"15;0", true},
+		{"7;8", true},
+		{"0;7", false},
+		{"0;default;12", false},
+	}
+	for _, tt := range envTests {
+		if c, ok := envColors(tt.in); !ok || c.Dark != tt.dark {
+			t.Errorf("envColors(%q): expected dark to be %v", tt.in, tt.dark)
+		}
+	}
+	for _, env := range []string{"", "15", "15;default", "15;16"} {
+		if _, ok := envColors(env); ok {
+			t.Errorf("envColors(%q): expected to be invalid", env)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// darkLuminance is the luminance of a middle gray, with a lightness of 50 in
+// CIELAB, under which a background is dark.
+const darkLuminance = 0.184
+
+// Colors represents the default colors of the terminal.
+type Colors struct {
+	Foreground, Background Color
+
+	Luminance float64 // Luminance of the background.
+	Dark      bool    // The background is dark.
+}
+
+func newColors(fg, bg Color) Colors {
+	lum := bg.Luminance()
+	return Colors{fg, bg, lum, lum < darkLuminance}
+}
+
+// DefaultColors returns the default foreground and background colors of the
+// terminal, got through the control sequences OSC 10 and OSC 11.
+//
+// When the terminal does not reply, they are got from the environment variable
+// COLORFGBG, set by rxvt and Konsole like "15;0", with the indexes of the colors
+// in the palette of 16 colors. Then, the background is dark for the colors 0 to
+// 6 and 8, like in rxvt.
+//
+// The primary device attributes are requested after, so it does not wait for
+// the timeout when the terminal does not support those sequences.
+func (t *Terminal) DefaultColors() (Colors, error) {
+	var fg, bg Color
+	var hasFg, hasBg bool
+
+	err := t.Query("\033]10;?\033\\\033]11;?\033\\\033[c", QueryTimeout,
+		func(seq []byte) (bool, bool) {
+			if c, ok := oscColor(seq, "10"); ok {
+				fg, hasFg = c, true
+				return true, false
+			}
+			if c, ok := oscColor(seq, "11"); ok {
+				bg, hasBg = c, true
+				return true, false
+			}
+			if _, ok := csiParams(seq, '?', 'c'); ok {
+				return true, true
+			}
+			return false, false
+		})
+
+	if err == nil && hasBg {
+		// Without foreground, it is the opposite to the background.
+		if !hasFg && bg.Luminance() < darkLuminance {
+			fg = Color{0xffff, 0xffff, 0xffff}
+		}
+		return newColors(fg, bg), nil
+	}
+
+	if c, ok := envColors(os.Getenv("COLORFGBG")); ok {
+		return c, nil
+	}
+	if err == nil {
+		err = ErrNoReply
+	}
+	return Colors{}, err
+}
+
+// oscColor returns the color of the reply to an OSC query, in the format
+// "ESC ] code ; rgb:R/G/B ST", with from 1 to 4 hexadecimal digits per channel.
+func oscColor(seq []byte, code string) (c Color, ok bool) {
+	prefix := "\033]" + code + ";rgb:"
+	if !bytes.HasPrefix(seq, []byte(prefix)) {
+		return
+	}
+	s := string(seq[len(prefix):])
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "\a"), "\033\\")
+
+	rgb := strings.Split(s, "/")
+	if len(rgb) != 3 {
+		return
+	}
+
+	var v [3]uint16
+	for i, hex := range rgb {
+		if len(hex) == 0 || len(hex) > 4 {
+			return
+		}
+		n, err := strconv.ParseUint(hex, 16, 16)
+		if err != nil {
+			return
+		}
+		max := uint64(1)<<(4*uint(len(hex))) - 1
+		v[i] = uint16(n * 0xffff / max)
+	}
+	return Color{v[0], v[1], v[2]}, true
+}
+
+// envColors returns the colors set in the format of COLORFGBG, "fg;bg" or
+// "fg;default;bg".
+func envColors(env string) (c Colors, ok bool) {
+	fields := strings.Split(env, ";")
+	if len(fields) < 2 {
+		return
+	}
+
+	index := func(s string) (int, bool) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n >= len(ANSIColors) {
+			return 0, false
+		}
+		return n, true
+	}
+
+	bg, ok := index(fields[len(fields)-1])
+	if !ok {
+		return
+	}
+	fg, ok := index(fields[0])
+	if !ok {
+		fg = 7
+		if bg == 7 || bg > 8 {
+			fg = 0
+		}
+	}
+
+	c = newColors(ANSIColors[fg], ANSIColors[bg])
+	c.Dark = bg <= 6 || bg == 8
+	return c, true
+}
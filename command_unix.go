@@ -0,0 +1,218 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// StartCommand starts the command attached to a new pseudo-terminal, and
+// returns the master side of it.
+//
+// The command is run as leader of a new session, having the slave side as its
+// controlling terminal. The standard input, output and error of the command
+// which are not set are connected to the slave side.
+func StartCommand(cmd *exec.Cmd) (*os.File, error) {
+	return startCommand(cmd, nil)
+}
+
+// StartCommandSize is like StartCommand, but setting the size of the
+// pseudo-terminal before the command is started, since it is zero in a new one.
+func StartCommandSize(cmd *exec.Cmd, row, column int) (*os.File, error) {
+	return startCommand(cmd, &winsize{Row: uint16(row), Col: uint16(column)})
+}
+
+func startCommand(cmd *exec.Cmd, ws *winsize) (*os.File, error) {
+	master, slave, err := openpty()
+	if err != nil {
+		return nil, err
+	}
+	defer slave.Close()
+
+	if ws != nil {
+		if err = setWinsize(int(slave.Fd()), ws); err != nil {
+			master.Close()
+			return nil, fmt.Errorf("terminal: could not set size: %s", err)
+		}
+	}
+
+	if cmd.Stdin == nil {
+		cmd.Stdin = slave
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = slave
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = slave
+	}
+
+	// The controlling terminal is set from a file descriptor in the child.
+	ctty := 0
+	if cmd.Stdin != slave {
+		cmd.ExtraFiles = append(cmd.ExtraFiles, slave)
+		ctty = 2 + len(cmd.ExtraFiles)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = ctty
+
+	if err = cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+// RunCommand runs the command attached to a new pseudo-terminal, relaying the
+// standard input and output of the actual process to it, and waits for it to
+// complete.
+//
+// The terminal of the standard input is put in raw mode until the command
+// exits, and the changes of its window size are forwarded to the command.
+func RunCommand(cmd *exec.Cmd) error {
+	return RunCommandRelay(cmd, Relay{})
+}
+
+// A Relay sets how RunCommandRelay relays the terminal to the command.
+type Relay struct {
+	// Output is where the output of the command is written; the standard
+	// output if it is nil.
+	Output io.Writer
+
+	// Input gets a copy of the input sent to the command, if it is not nil.
+	Input io.Writer
+
+	// Resize is called with the window size at starting the command, and at
+	// every change, if it is not nil.
+	Resize func(rows, columns int)
+}
+
+// RunCommandRelay is like RunCommand, relaying the terminal as it is set in
+// relay.
+//
+// The standard input is read until the command exits, so the input typed
+// after is kept for the actual process.
+func RunCommandRelay(cmd *exec.Cmd, relay Relay) error {
+	term, err := New(syscall.Stdin)
+	if err != nil {
+		return err
+	}
+	if relay.Output == nil {
+		relay.Output = os.Stdout
+	}
+
+	ws := new(winsize)
+	if err = getWinsize(term.fd, ws); err != nil {
+		ws = nil
+	}
+	master, err := startCommand(cmd, ws)
+	if err != nil {
+		return err
+	}
+	defer master.Close()
+
+	// The command is not left running after of an error.
+	fail := func(err error) error {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	conn, err := master.SyscallConn()
+	if err != nil {
+		return fail(err)
+	}
+	resize := func() {
+		ws := new(winsize)
+
+		if getWinsize(term.fd, ws) == nil {
+			conn.Control(func(fd uintptr) { setWinsize(int(fd), ws) })
+			if relay.Resize != nil {
+				relay.Resize(int(ws.Row), int(ws.Col))
+			}
+		}
+	}
+	resize()
+
+	// == Forward changes of window size.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sizes := term.NotifyResize(ctx)
+
+	go func() {
+		for _ = range sizes {
+			resize()
+		}
+	}()
+
+	if err = term.RawMode(); err != nil {
+		return fail(err)
+	}
+	defer term.Restore()
+
+	// Read returns after of a tenth of second without input, to stop reading
+	// when the command exits.
+	state := term.lastState
+	state.Cc[VMIN] = 0
+	state.Cc[VTIME] = 1
+	if err = tcsetattr(term.fd, _TCSANOW, &state); err != nil {
+		return fail(fmt.Errorf("terminal: could not set raw mode: %s", err))
+	}
+	term.lastState = state
+
+	var in io.Reader = inputReader{ctx, term.fd}
+	if relay.Input != nil {
+		in = io.TeeReader(in, relay.Input)
+	}
+	copied := make(chan bool)
+	go func() {
+		io.Copy(master, in)
+		close(copied)
+	}()
+
+	io.Copy(relay.Output, master) // until the slave side is closed
+	err = cmd.Wait()
+
+	cancel()
+	<-copied
+	return err
+}
+
+// inputReader reads from a file descriptor whose reads return without input,
+// until the context is done.
+type inputReader struct {
+	ctx context.Context
+	fd  int
+}
+
+func (r inputReader) Read(p []byte) (int, error) {
+	for r.ctx.Err() == nil {
+		n, err := syscall.Read(r.fd, p)
+		if n > 0 {
+			return n, nil
+		}
+		switch err {
+		case nil, syscall.EINTR: // no input
+		case syscall.EAGAIN: // non-blocking
+			sleepContext(r.ctx)
+		default:
+			return 0, err
+		}
+	}
+	return 0, io.EOF
+}
@@ -9,3 +9,12 @@
 package terminal
 
 //cgo const (TCGETS, TCSETS, TCSETSW, TCSETSF)
+
+// Pseudo-terminals
+//cgo const (TIOCGPTN, TIOCSPTLCK)
+
+// Arbitrary baud rates
+//cgo const (CBAUD, CIBAUD, IBSHIFT, BOTHER, TCGETS2, TCSETS2)
+//cgo const (B460800, B500000, B576000, B921600, B1000000, B1152000, B1500000,
+// B2000000, B2500000, B3000000, B3500000, B4000000)
+//cgo type struct_termios2
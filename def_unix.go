@@ -14,7 +14,7 @@ Linux file: "asm-generic/termbits.h"
 package terminal
 
 //cgo const (TCSANOW, TCSADRAIN, TCSAFLUSH)
-//cgo const TIOCGWINSZ
+//cgo const (TIOCGWINSZ, TIOCSWINSZ)
 
 //cgo type struct_termios
 //cgo type struct_winsize
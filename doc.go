@@ -25,5 +25,8 @@ The "go test" tool runs tests with standard input connected to standard
 error. So whatever program that uses the file descriptor of "/dev/stdin"
 (which is 0), then it is going to fail. The solution is to use the standard
 error.
+
+The output of a program can be checked without a terminal, writing it to the
+virtual screen of package vt.
 */
 package terminal
@@ -7,8 +7,12 @@
 package editline
 
 import (
-	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"unicode/utf8"
+
+	"github.com/kless/terminal/ansi"
 )
 
 // Buffer size
@@ -29,11 +33,16 @@ func init() {
 
 // A buffer represents the line buffer.
 type buffer struct {
+	mu sync.Mutex // Held while the line is changed
+
 	columns   int // Number of columns for actual window
 	promptLen int
+	prompt    string // Written in place of the first promptLen characters, since it can have ANSI codes
 	pos       int    // Pointer position into buffer
 	size      int    // Amount of characters added
 	data      []rune // Text buffer
+	row       int    // Row of the cursor in the screen, from the first one of the line
+	lastRow   int    // Last row written since the prompt, from the first one of the line
 }
 
 func newBuffer(promptLen, columns int) *buffer {
@@ -50,59 +59,41 @@ func newBuffer(promptLen, columns int) *buffer {
 
 // insertRune inserts a character in the cursor position.
 func (b *buffer) insertRune(r rune) error {
-	var useRefresh bool
-
 	b.grow(b.size + 1) // Check if there is free space for one more character
 
 	// Avoid a full update of the line.
 	if b.pos == b.size {
-		char := make([]byte, utf8.UTFMax)
-		utf8.EncodeRune(char, r)
-
-		if _, err := Output.Write(char); err != nil {
-			return outputError(err.Error())
-		}
-	} else {
-		useRefresh = true
-		copy(b.data[b.pos+1:b.size+1], b.data[b.pos:b.size])
+		b.data[b.pos] = r
+		b.pos++
+		b.size++
+		return b.render(b.pos-1, b.pos)
 	}
 
+	copy(b.data[b.pos+1:b.size+1], b.data[b.pos:b.size])
 	b.data[b.pos] = r
 	b.pos++
 	b.size++
-
-	if useRefresh {
-		return b.refresh()
-	}
-	return nil
+	return b.refresh()
 }
 
-// insertRunes inserts several characters.
+// insertRunes inserts several characters, updating the line only once when
+// they are not added at the end.
 func (b *buffer) insertRunes(runes []rune) error {
-	for _, r := range runes {
-		if err := b.insertRune(r); err != nil {
-			return err
-		}
-	}
-	return nil
-}
+	b.grow(b.size + len(runes))
 
-// toBytes returns a slice of the contents of the buffer.
-func (b *buffer) toBytes() []byte {
-	chars := make([]byte, b.size*utf8.UTFMax)
-	var end, runeLen int
-
-	// == Each character (as integer) is encoded to []byte
-	for i := 0; i < b.size; i++ {
-		if i != 0 {
-			runeLen = utf8.EncodeRune(chars[end:], b.data[i])
-			end += runeLen
-		} else {
-			runeLen = utf8.EncodeRune(chars, b.data[i])
-			end = runeLen
-		}
+	if b.pos == b.size {
+		copy(b.data[b.pos:], runes)
+		b.pos += len(runes)
+		b.size += len(runes)
+		return b.render(b.pos-len(runes), b.pos)
 	}
-	return chars[:end]
+
+	copy(b.data[b.pos+len(runes):b.size+len(runes)], b.data[b.pos:b.size])
+	copy(b.data[b.pos:], runes)
+	b.pos += len(runes)
+	b.size += len(runes)
+
+	return b.refresh()
 }
 
 // toString returns the contents of the buffer as a string.
@@ -110,81 +101,163 @@ func (b *buffer) toString() string { return string(b.data[b.promptLen:b.size]) }
 
 // refresh refreshes the line.
 func (b *buffer) refresh() (err error) {
-	lastLine, _ := b.pos2xy(b.size)
-	posLine, posColumn := b.pos2xy(b.pos)
-
 	// To the first line.
-	for ln := posLine; ln > 0; ln-- {
-		if _, err = Output.Write(toPreviousLine); err != nil {
-			return outputError(err.Error())
-		}
+	if _, err = io.WriteString(Output, ansi.CursorPreviousLine(b.row)); err != nil {
+		return outputError(err.Error())
 	}
+	b.row = 0
 
 	// == Write the line
 	if _, err = Output.Write(_CR); err != nil {
 		return outputError(err.Error())
 	}
-	if _, err = Output.Write(b.toBytes()); err != nil {
-		return outputError(err.Error())
+	if err = b.render(0, b.size); err != nil {
+		return err
 	}
-	if _, err = Output.Write(delToRight); err != nil {
+	// The rows of the line written before could be more.
+	if _, err = io.WriteString(Output, ansi.EraseDisplay(ansi.EraseToEnd)); err != nil {
 		return outputError(err.Error())
 	}
 
 	// == Move cursor to original position.
-	for ln := lastLine; ln > posLine; ln-- {
-		if _, err = Output.Write(toPreviousLine); err != nil {
-			return outputError(err.Error())
-		}
+	lastLine := b.row
+	posLine, posColumn := b.pos2xy(b.pos)
+
+	if _, err = io.WriteString(Output, ansi.CursorPreviousLine(lastLine-posLine)); err != nil {
+		return outputError(err.Error())
 	}
-	if _, err = fmt.Fprintf(Output, "\r\033[%dC", posColumn); err != nil {
+	if _, err = io.WriteString(Output, "\r"+ansi.CursorForward(posColumn)); err != nil {
 		return outputError(err.Error())
 	}
-
+	b.row = posLine
 	return nil
 }
 
-// == Movement
+// redraw writes the line from the start of the actual row, like after of
+// resuming the process, and moves the cursor to its position.
+func (b *buffer) redraw() error {
+	b.row = 0 // the cursor is at the first line
+	return b.refresh()
+}
 
-// start moves the cursor at the start.
-func (b *buffer) start() (err error) {
-	if b.pos == b.promptLen {
-		return
-	}
+// render writes the characters from the position from until to, being the
+// cursor at the position from. The cursor is moved to the next row when the
+// text written fills the last column, since the terminal keeps the cursor in
+// that column until the next character is written.
+//
+// The line breaks erase the rest of the row, the tabs are written as spaces
+// until the next tab stop, and the rest of control characters are written in
+// caret notation, like "^[".
+func (b *buffer) render(from, to int) error {
+	line, col := b.pos2xy(from)
+	out := make([]byte, 0, (to-from)*utf8.UTFMax)
+
+	if from < b.promptLen && b.prompt != "" {
+		out = append(out, b.prompt...)
+		if line, col = b.pos2xy(b.promptLen); col == 0 && line != 0 {
+			out = append(out, CRLF...)
+		}
+		from = b.promptLen
+	}
+	for i := from; i < to; i++ {
+		if i >= b.promptLen && b.data[i] == '\n' {
+			out = append(out, ansi.EraseLine(ansi.EraseToEnd)...)
+			out = append(out, CRLF...)
+			line, col = line+1, 0
+			continue
+		}
 
-	for ln, _ := b.pos2xy(b.pos); ln > 0; ln-- {
-		if _, err = Output.Write(CursorUp); err != nil {
-			return outputError(err.Error())
+		for _, r := range b.cells(i, col) {
+			out = append(out, string(r)...)
+			if col++; col == b.columns {
+				out = append(out, CRLF...)
+				line, col = line+1, 0
+			}
 		}
 	}
 
-	if _, err = fmt.Fprintf(Output, "\r\033[%dC", b.promptLen); err != nil {
+	b.row = line
+	if line > b.lastRow {
+		b.lastRow = line
+	}
+	if _, err := Output.Write(out); err != nil {
 		return outputError(err.Error())
 	}
-	b.pos = b.promptLen
-	return
+	return nil
+}
+
+// tabWidth is the distance between the tab stops.
+const tabWidth = 8
+
+// cells returns the text written for the character at the position i, at the
+// column col, where every character is written in a column.
+func (b *buffer) cells(i, col int) string {
+	r := b.data[i]
+
+	switch {
+	case i < b.promptLen:
+	case r == '\t':
+		n := tabWidth - col%tabWidth
+		if b.columns != 0 && col+n > b.columns {
+			n = b.columns - col
+		}
+		return strings.Repeat(" ", n)
+	case r < 0x20 || r == 0x7f:
+		return string([]rune{'^', r ^ 0x40})
+	}
+	return string(r)
+}
+
+// == Movement
+
+// start moves the cursor at the start.
+func (b *buffer) start() error {
+	return b.setPos(b.promptLen)
 }
 
 // end moves the cursor at the end.
 // Returns the number of lines that fill in the data.
 func (b *buffer) end() (lines int, err error) {
-	if b.pos == b.size {
-		return
+	if err = b.setPos(b.size); err != nil {
+		return 0, err
+	}
+	return b.row, nil
+}
+
+// setPos moves the cursor to a position, which is limited to the start and the
+// end of the line.
+func (b *buffer) setPos(pos int) (err error) {
+	if pos < b.promptLen {
+		pos = b.promptLen
+	} else if pos > b.size {
+		pos = b.size
 	}
 
-	lastLine, lastColumn := b.pos2xy(b.size)
+	_, column := b.pos2xy(b.pos)
+	newLine, newColumn := b.pos2xy(pos)
 
-	for ln, _ := b.pos2xy(b.pos); ln < lastLine; ln++ {
-		if _, err = Output.Write(cursorDown); err != nil {
-			return 0, outputError(err.Error())
+	// In the same row, it is only moved the column.
+	if newLine == b.row {
+		if _, err = io.WriteString(Output, ansi.CursorBackward(column-newColumn)+
+			ansi.CursorForward(newColumn-column)); err != nil {
+			return outputError(err.Error())
 		}
+		b.pos = pos
+		return
 	}
 
-	if _, err = fmt.Fprintf(Output, "\r\033[%dC", lastColumn); err != nil {
-		return 0, outputError(err.Error())
+	if _, err = io.WriteString(Output, ansi.CursorUp(b.row-newLine)); err != nil {
+		return outputError(err.Error())
 	}
-	b.pos = b.size
-	return lastLine, nil
+	if _, err = io.WriteString(Output, ansi.CursorDown(newLine-b.row)); err != nil {
+		return outputError(err.Error())
+	}
+
+	if _, err = io.WriteString(Output, "\r"+ansi.CursorForward(newColumn)); err != nil {
+		return outputError(err.Error())
+	}
+	b.pos, b.row = pos, newLine
+	return
 }
 
 // backward moves the cursor one character backward.
@@ -193,22 +266,7 @@ func (b *buffer) backward() (start bool, err error) {
 	if b.pos == b.promptLen {
 		return true, nil
 	}
-	b.pos--
-
-	// If position is on the same line.
-	if _, col := b.pos2xy(b.pos); col != 0 {
-		if _, err = Output.Write(cursorBackward); err != nil {
-			return false, outputError(err.Error())
-		}
-	} else {
-		if _, err = Output.Write(CursorUp); err != nil {
-			return false, outputError(err.Error())
-		}
-		if _, err = fmt.Fprintf(Output, "\033[%dC", b.columns); err != nil {
-			return false, outputError(err.Error())
-		}
-	}
-	return
+	return false, b.setPos(b.pos - 1)
 }
 
 // forward moves the cursor one character forward.
@@ -217,18 +275,7 @@ func (b *buffer) forward() (end bool, err error) {
 	if b.pos == b.size {
 		return true, nil
 	}
-	b.pos++
-
-	if _, col := b.pos2xy(b.pos); col != 0 {
-		if _, err = Output.Write(cursorForward); err != nil {
-			return false, outputError(err.Error())
-		}
-	} else {
-		if _, err = Output.Write(toNextLine); err != nil {
-			return false, outputError(err.Error())
-		}
-	}
-	return
+	return false, b.setPos(b.pos + 1)
 }
 
 // swap swaps the actual character by the previous one. If it is the end of the
@@ -260,7 +307,6 @@ func (b *buffer) wordBackward() (err error) {
 			return
 		}
 	}
-	panic("unreachable")
 }
 
 // wordForward moves the cursor one word forward.
@@ -271,7 +317,6 @@ func (b *buffer) wordForward() (err error) {
 			return
 		}
 	}
-	panic("unreachable")
 }
 
 // == Delete
@@ -281,12 +326,13 @@ func (b *buffer) deleteChar() (err error) {
 	if b.pos == b.size {
 		return
 	}
+	plain := b.plain(b.pos, b.size)
 
 	copy(b.data[b.pos:], b.data[b.pos+1:b.size])
 	b.size--
 
-	if lastLine, _ := b.pos2xy(b.size); lastLine == 0 {
-		if _, err = Output.Write(delChar); err != nil {
+	if lastLine, _ := b.pos2xy(b.size); lastLine == 0 && plain {
+		if _, err = io.WriteString(Output, ansi.DeleteChars(1)); err != nil {
 			return outputError(err.Error())
 		}
 		return nil
@@ -299,13 +345,14 @@ func (b *buffer) deleteCharPrev() (err error) {
 	if b.pos == b.promptLen {
 		return
 	}
+	plain := b.plain(b.pos-1, b.size)
 
 	copy(b.data[b.pos-1:], b.data[b.pos:b.size])
 	b.pos--
 	b.size--
 
-	if lastLine, _ := b.pos2xy(b.size); lastLine == 0 {
-		if _, err = Output.Write(delBackspace); err != nil {
+	if lastLine, _ := b.pos2xy(b.size); lastLine == 0 && plain {
+		if _, err = io.WriteString(Output, ansi.CursorBackward(1)+ansi.DeleteChars(1)); err != nil {
 			return outputError(err.Error())
 		}
 		return nil
@@ -319,23 +366,8 @@ func (b *buffer) deleteToRight() (err error) {
 		return
 	}
 
-	lastLine, _ := b.pos2xy(b.size)
-	posLine, _ := b.pos2xy(b.pos)
-
-	// To the last line.
-	for ln := posLine; ln < lastLine; ln++ {
-		if _, err = Output.Write(cursorDown); err != nil {
-			return outputError(err.Error())
-		}
-	}
-	// Delete all lines until the cursor position.
-	for ln := lastLine; ln > posLine; ln-- {
-		if _, err = Output.Write(delLine_cursorUp); err != nil {
-			return outputError(err.Error())
-		}
-	}
-
-	if _, err = Output.Write(delToRight); err != nil {
+	// Delete the rest of the row, and all rows below.
+	if _, err = io.WriteString(Output, ansi.EraseDisplay(ansi.EraseToEnd)); err != nil {
 		return outputError(err.Error())
 	}
 	b.size = b.pos
@@ -350,11 +382,12 @@ func (b *buffer) deleteLine() error {
 	}
 
 	for lines > 0 {
-		if _, err = Output.Write(delLine_cursorUp); err != nil {
+		if _, err = io.WriteString(Output, eraseLineUp); err != nil {
 			return outputError(err.Error())
 		}
 		lines--
 	}
+	b.row = 0
 	return nil
 }
 
@@ -363,18 +396,50 @@ func (b *buffer) deleteLine() error {
 // grow grows buffer to guarantee space for n more byte.
 func (b *buffer) grow(n int) {
 	for n > len(b.data) {
-		b.data = b.data[:len(b.data)+BufferLen]
+		b.data = append(b.data, make([]rune, BufferLen)...)
 	}
 }
 
+// plain reports whether the characters between the positions from and to are
+// written each one in a column, so they can be moved like the terminal does at
+// deleting characters.
+func (b *buffer) plain(from, to int) bool {
+	for _, r := range b.data[from:to] {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// xy2pos returns the position at the coordinates, or the last one before of
+// them.
+func (b *buffer) xy2pos(line, column int) int {
+	pos := b.promptLen
+	for p := b.promptLen + 1; p <= b.size; p++ {
+		l, c := b.pos2xy(p)
+		if l > line || l == line && c > column {
+			break
+		}
+		pos = p
+	}
+	return pos
+}
+
 // pos2xy returns the coordinates of a position for a line of size given in
 // columns.
 func (b *buffer) pos2xy(pos int) (line, column int) {
-	if pos < b.columns {
-		return 0, pos
-	}
+	for i := 0; i < pos; i++ {
+		if i >= b.promptLen && b.data[i] == '\n' {
+			line, column = line+1, 0
+			continue
+		}
 
-	line = pos / b.columns
-	column = pos - (line * b.columns) //- 1
+		column += utf8.RuneCountInString(b.cells(i, column))
+		for b.columns != 0 && column >= b.columns {
+			line++
+			column -= b.columns
+		}
+	}
 	return
 }
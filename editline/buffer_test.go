@@ -0,0 +1,265 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package editline
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/kless/terminal"
+	"github.com/kless/terminal/vt"
+)
+
+// screenBuffer returns a buffer with the prompt, whose output is drawn in a
+// virtual screen. The output has to be restored at the end.
+func screenBuffer(t *testing.T, prompt string, cols int) (*buffer, *vt.Screen) {
+	scr := vt.New(5, cols)
+	Output = scr
+
+	b := newBuffer(len(prompt), cols)
+	if err := b.insertRunes([]rune(prompt)); err != nil {
+		t.Fatal(err)
+	}
+	return b, scr
+}
+
+// checkScreen checks the text of the screen, and the cursor position got from
+// the buffer and from the screen.
+func checkScreen(t *testing.T, op string, b *buffer, scr *vt.Screen, text string) {
+	if got := scr.String(); got != text {
+		t.Errorf("%s: expected screen %q, got %q", op, text, got)
+	}
+
+	row, col := scr.Cursor()
+	if line, column := b.pos2xy(b.pos); row != line || col != column {
+		t.Errorf("%s: cursor at %d,%d in screen, but at %d,%d in buffer",
+			op, row, col, line, column)
+	}
+}
+
+func TestInsertRune(t *testing.T) {
+	var out bytes.Buffer
+	defer func(w io.Writer) { Output = w }(Output)
+	Output = &out
+
+	b := newBuffer(0, 80)
+	for _, r := range "añ€" {
+		if err := b.insertRune(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.String() != "añ€" {
+		t.Errorf("unexpected output: %q", out.Bytes())
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	defer func(w io.Writer) { Output = w }(Output)
+	b, scr := screenBuffer(t, "$ ", 10)
+
+	b.insertRunes([]rune("hello world!"))
+	checkScreen(t, "insert", b, scr, "$ hello wo\nrld!")
+
+	b.setPos(4)
+	checkScreen(t, "setPos", b, scr, "$ hello wo\nrld!")
+
+	b.insertRune('X')
+	checkScreen(t, "insert in middle", b, scr, "$ heXllo w\norld!")
+
+	b.insertRunes([]rune("123456"))
+	checkScreen(t, "insert several", b, scr, "$ heX12345\n6llo world\n!")
+
+	b.swap()
+	checkScreen(t, "swap", b, scr, "$ heX12345\nl6lo world\n!")
+
+	// The line fills the second row.
+	b.deleteCharPrev()
+	checkScreen(t, "delete previous", b, scr, "$ heX12345\nllo world!")
+
+	b.deleteChar()
+	checkScreen(t, "delete", b, scr, "$ heX12345\nlo world!")
+
+	b.start()
+	checkScreen(t, "start", b, scr, "$ heX12345\nlo world!")
+
+	b.end()
+	checkScreen(t, "end", b, scr, "$ heX12345\nlo world!")
+
+	b.wordBackward()
+	checkScreen(t, "word backward", b, scr, "$ heX12345\nlo world!")
+}
+
+func TestWrapAtEnd(t *testing.T) {
+	defer func(w io.Writer) { Output = w }(Output)
+	b, scr := screenBuffer(t, "$ ", 10)
+
+	// The line is full, so the cursor is in the next row.
+	b.insertRunes([]rune("12345678"))
+	checkScreen(t, "insert", b, scr, "$ 12345678")
+
+	b.insertRune('9')
+	checkScreen(t, "insert", b, scr, "$ 12345678\n9")
+
+	b.backward()
+	checkScreen(t, "backward", b, scr, "$ 12345678\n9")
+
+	b.backward()
+	checkScreen(t, "backward", b, scr, "$ 12345678\n9")
+
+	b.forward()
+	b.forward()
+	checkScreen(t, "forward", b, scr, "$ 12345678\n9")
+
+	b.setPos(3)
+	b.deleteChar()
+	checkScreen(t, "delete", b, scr, "$ 13456789")
+	b.insertRune('2')
+	checkScreen(t, "insert", b, scr, "$ 12345678\n9")
+}
+
+func TestDeleteToRight(t *testing.T) {
+	defer func(w io.Writer) { Output = w }(Output)
+	b, scr := screenBuffer(t, "$ ", 10)
+
+	b.insertRunes([]rune("abcdefghijklmnopqrstuvwxyz"))
+	checkScreen(t, "insert", b, scr, "$ abcdefgh\nijklmnopqr\nstuvwxyz")
+
+	b.setPos(5)
+	b.deleteToRight()
+	checkScreen(t, "delete to right", b, scr, "$ abc")
+	if b.toString() != "abc" {
+		t.Errorf("unexpected buffer: %q", b.toString())
+	}
+
+	// Like Ctrl+U
+	b.insertRunes([]rune("defghijklmnop"))
+	b.setPos(3)
+	b.deleteLine()
+	ln := &Line{lenPS1: 2, ps1: "$ ", buf: b}
+	ln.Prompt()
+	checkScreen(t, "delete line", b, scr, "$")
+}
+
+func TestPrompt(t *testing.T) {
+	defer func(w io.Writer) { Output = w }(Output)
+	b, scr := screenBuffer(t, "> ", 10)
+
+	b.insertRunes([]rune("old"))
+	ln := &Line{lenPS1: 2, ps1: "$ ", buf: b}
+	if err := ln.Prompt(); err != nil {
+		t.Fatal(err)
+	}
+	checkScreen(t, "prompt", b, scr, "$")
+
+	b.insertRunes([]rune("new"))
+	checkScreen(t, "insert", b, scr, "$ new")
+}
+
+func TestRenderPasted(t *testing.T) {
+	defer func(w io.Writer) { Output = w }(Output)
+	b, scr := screenBuffer(t, "$ ", 10)
+
+	b.insertRunes(pastedRunes("ab\rc\td\x1be"))
+	checkScreen(t, "insert", b, scr, "$ ab\nc       d^\n[e")
+	if b.toString() != "ab\nc\td\x1be" {
+		t.Errorf("unexpected buffer: %q", b.toString())
+	}
+
+	b.setPos(4)
+	checkScreen(t, "setPos", b, scr, "$ ab\nc       d^\n[e")
+
+	b.backward()
+	checkScreen(t, "backward", b, scr, "$ ab\nc       d^\n[e")
+
+	// Join the first two lines.
+	b.forward()
+	b.forward()
+	checkScreen(t, "forward", b, scr, "$ ab\nc       d^\n[e")
+	b.deleteCharPrev()
+	checkScreen(t, "delete line break", b, scr, "$ abc   d^\n[e")
+
+	b.insertRune('\n')
+	checkScreen(t, "insert line break", b, scr, "$ ab\nc       d^\n[e")
+
+	b.end()
+	checkScreen(t, "end", b, scr, "$ ab\nc       d^\n[e")
+}
+
+func TestMoveToClick(t *testing.T) {
+	defer func(w io.Writer) { Output = w }(Output)
+	b, scr := screenBuffer(t, "$ ", 10)
+
+	pty, err := terminal.OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+	if err = pty.SetSize(5, 10, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	b.insertRunes([]rune("hello world!\nbye"))
+	checkScreen(t, "insert", b, scr, "$ hello wo\nrld!\nbye")
+
+	// The line starts in the row 2 of the screen.
+	ln := &Line{buf: b, term: pty.Terminal, top: 2}
+
+	tests := []struct {
+		x, y int
+		pos  int
+	}{
+		{3, 2, 2},  // start
+		{1, 2, 2},  // prompt
+		{3, 3, 12}, // row 2
+		{9, 3, 14}, // after of the end of row 2
+		{2, 4, 16}, // row 3
+		{1, 1, 2},  // before of the line
+		{5, 5, 18}, // after of the line
+	}
+	for _, tt := range tests {
+		if err = ln.moveToClick(tt.x, tt.y); err != nil {
+			t.Fatal(err)
+		}
+		if b.pos != tt.pos {
+			t.Errorf("click at %d,%d: expected position %d, got %d", tt.x, tt.y, tt.pos, b.pos)
+		}
+		checkScreen(t, "click", b, scr, "$ hello wo\nrld!\nbye")
+	}
+
+	// The screen is scrolled.
+	b.insertRunes([]rune("\n1\n2"))
+	ln.top = 4
+	if err = ln.moveToClick(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if b.pos != 10 {
+		t.Errorf("expected position 10 after of scrolling, got %d", b.pos)
+	}
+
+	// Unknown row of the line.
+	ln.top = 0
+	if err = ln.moveToClick(1, 1); err != nil || b.pos != 10 {
+		t.Errorf("expected to not move, got position %d", b.pos)
+	}
+}
+
+func TestRenderPromptANSI(t *testing.T) {
+	defer func(w io.Writer) { Output = w }(Output)
+	b, scr := screenBuffer(t, "", 10)
+
+	// The prompt has 3 columns.
+	b.prompt = "\033[1m>\033[0m  "
+	b.promptLen, b.pos, b.size = 3, 3, 3
+
+	b.insertRunes([]rune("abc"))
+	b.start()
+	if err := b.insertRune('x'); err != nil { // the line is refreshed
+		t.Fatal(err)
+	}
+	checkScreen(t, "prompt with ANSI codes", b, scr, ">  xabc")
+}
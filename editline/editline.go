@@ -34,6 +34,14 @@
 //
 //   Ctrl+c
 //   Ctrl+d : exit
+//   Ctrl+z : suspend, to be resumed like a job of the shell
+//
+// The cursor is moved to the position clicked, when the mouse is enabled through
+// EnableMouse.
+//
+// The text pasted is inserted in the line, when the terminal supports the
+// bracketed paste mode, so it is not run until Enter is pressed. Its line breaks
+// are kept, so a text of several lines is edited and returned as a single line.
 //
 // Note that There are several default values:
 //
@@ -46,7 +54,7 @@
 package editline
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -54,6 +62,7 @@ import (
 	"syscall"
 
 	"github.com/kless/terminal"
+	"github.com/kless/terminal/keys"
 )
 
 // Default values for prompts.
@@ -73,7 +82,7 @@ var (
 var ChanCtrlC = make(chan byte)
 
 func init() {
-	if !terminal.CheckANSI() {
+	if !terminal.SupportANSI() {
 		panic("Your terminal does not support ANSI")
 	}
 }
@@ -87,6 +96,9 @@ type Line struct {
 	buf        *buffer  // Text buffer
 	hist       *history // History file
 	term       *terminal.Terminal
+	dec        *keys.Decoder // Key events from input
+	mouse      bool          // The mouse reports are enabled
+	top        int           // Row of the screen where the line starts; zero if unknown
 }
 
 // NewLine returns a line using both prompts ps1 and ps2, and setting the TTY to
@@ -98,7 +110,11 @@ func NewLine(ps1, ps2 string, lenAnsi int, hist *history) (*Line, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err = term.MakeRaw(); err != nil {
+	if err = term.RawMode(); err != nil {
+		return nil, err
+	}
+	if err = term.EnableBracketedPaste(); err != nil {
+		term.Restore()
 		return nil, err
 	}
 
@@ -109,7 +125,7 @@ func NewLine(ps1, ps2 string, lenAnsi int, hist *history) (*Line, error) {
 	}
 
 	buf := newBuffer(lenPS1, col)
-	buf.insertRunes([]rune(ps1))
+	buf.prompt = ps1
 
 	return &Line{
 		hasHistory(hist),
@@ -119,6 +135,9 @@ func NewLine(ps1, ps2 string, lenAnsi int, hist *history) (*Line, error) {
 		buf,
 		hist,
 		term,
+		keys.NewDecoder(newLineInput(term)),
+		false,
+		0,
 	}, nil
 }
 
@@ -130,7 +149,11 @@ func NewDefaultLine(hist *history) (*Line, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err = term.MakeRaw(); err != nil {
+	if err = term.RawMode(); err != nil {
+		return nil, err
+	}
+	if err = term.EnableBracketedPaste(); err != nil {
+		term.Restore()
 		return nil, err
 	}
 
@@ -140,7 +163,7 @@ func NewDefaultLine(hist *history) (*Line, error) {
 	}
 
 	buf := newBuffer(len(_PS1), col)
-	buf.insertRunes([]rune(_PS1))
+	buf.prompt = _PS1
 
 	return &Line{
 		hasHistory(hist),
@@ -150,14 +173,36 @@ func NewDefaultLine(hist *history) (*Line, error) {
 		buf,
 		hist,
 		term,
+		keys.NewDecoder(newLineInput(term)),
+		false,
+		0,
 	}, nil
 }
 
+// EnableMouse enables the reports of the mouse, to move the cursor where it is
+// clicked. Note that the terminal does not select the text with the mouse while
+// it is enabled.
+func (ln *Line) EnableMouse() error {
+	if err := ln.term.EnableMouse(terminal.MouseNormal, terminal.MouseSGR); err != nil {
+		return err
+	}
+	ln.mouse = true
+	return nil
+}
+
 // Restore restores the terminal settings, so it is disabled the raw mode.
 func (ln *Line) Restore() {
 	ln.term.Restore()
 }
 
+// Guard starts a guard which restores the terminal if the process is
+// terminated by a signal or by a panic, so the raw mode is not kept in the
+// shell. It has to be closed through a deferred call in the goroutine which
+// reads the lines.
+func (ln *Line) Guard() *terminal.Guard {
+	return ln.term.Guard()
+}
+
 // Read reads charactes from input to write them to output, enabling line editing.
 // The errors that could return are to indicate if Ctrl+D was pressed, and for
 // both input/output errors.
@@ -165,73 +210,150 @@ func (ln *Line) Read() (line string, err error) {
 	var anotherLine []rune // For lines got from history.
 	var isHistoryUsed bool // If the history has been accessed.
 
-	in := bufio.NewReader(Input) // Read input.
-	esc := make([]byte, 2)       // For escape sequences.
-	extEsc := make([]byte, 3)    // Extended escape sequences.
+	// The line is changed with the lock held, but while the input is waited.
+	ln.buf.mu.Lock()
+	defer ln.buf.mu.Unlock()
 
 	// Print the primary prompt.
 	if err = ln.Prompt(); err != nil {
 		return "", err
 	}
 
-	// == Detect change of window size.
-	terminal.TrapSize()
+	// The row of the line is got before of reading the keys, to know the
+	// position clicked.
+	ln.top = 0
+	if ln.mouse {
+		if row, _, err := ln.term.CursorPosition(); err == nil {
+			ln.top = row
+		}
+	}
+
+	// == Detect change of window size, until of returning.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sizes := ln.term.NotifyResize(ctx)
 
 	go func() {
-		for {
-			select {
-			case <-terminal.WinSizeChan: // Wait for.
-				_, col, err := ln.term.GetSize()
-				if err != nil {
-					ln.buf.columns = col
-					ln.buf.refresh()
-				}
+		for size := range sizes {
+			ln.buf.mu.Lock()
+			if ctx.Err() == nil {
+				// The terminal wraps the line again in the new size.
+				ln.buf.columns = size.Columns
+				ln.buf.row, _ = ln.buf.pos2xy(ln.buf.pos)
+				ln.buf.refresh()
 			}
+			ln.buf.mu.Unlock()
 		}
 	}()
 
 	for {
-		rune, _, err := in.ReadRune()
+		ln.buf.mu.Unlock()
+		ev, err := ln.dec.ReadEvent()
+		ln.buf.mu.Lock()
+
 		if err != nil {
 			return "", inputError(err.Error())
 		}
 
-		switch rune {
+		var key keys.KeyEvent
+
+		switch ev := ev.(type) {
+		case keys.KeyEvent:
+			key = ev
+		case keys.PasteEvent:
+			if err = ln.buf.insertRunes(pastedRunes(ev.Text)); err != nil {
+				return "", err
+			}
+			continue
+		case keys.MouseEvent:
+			if ev.Button == keys.ButtonLeft && ev.Action == keys.MousePress {
+				if err = ln.moveToClick(ev.X, ev.Y); err != nil {
+					return "", err
+				}
+			}
+			continue
+		default:
+			continue
+		}
+
+		switch key.Mod {
+		case 0:
+		case keys.ModCtrl:
+			goto _ctrl
 		default:
-			if err = ln.buf.insertRune(rune); err != nil {
+			if key.Key == keys.KeyLeft || key.Key == keys.KeyRight {
+				goto _ctrl
+			}
+			continue
+		}
+
+		switch key.Key {
+		case keys.KeyRune:
+			if err = ln.buf.insertRune(key.Rune); err != nil {
 				return "", err
 			}
 			continue
 
-		case 13: // enter
+		case keys.KeyEnter:
 			line = ln.buf.toString()
 
 			if ln.useHistory {
 				ln.hist.Add(line)
 			}
+			// The text pasted could have several lines.
+			if _, err = ln.buf.end(); err != nil {
+				return "", err
+			}
 			if _, err = Output.Write(CRLF); err != nil {
 				return "", outputError(err.Error())
 			}
 
 			return strings.TrimSpace(line), nil
 
-		case 127, 8: // backspace, Ctrl+h
-			if err = ln.buf.deleteCharPrev(); err != nil {
+		case keys.KeyBackspace:
+			goto _backspace
+
+		case keys.KeyTab:
+			// TODO: disabled by now
+			continue
+
+		case keys.KeyDelete:
+			if err = ln.buf.deleteChar(); err != nil {
 				return "", err
 			}
 			continue
 
-		case 9: // horizontal tab
-			// TODO: disabled by now
-			continue
+		case keys.KeyHome:
+			goto _start
+		case keys.KeyEnd:
+			goto _end
+		case keys.KeyLeft:
+			goto _leftArrow
+		case keys.KeyRight:
+			goto _rightArrow
+		case keys.KeyUp, keys.KeyDown:
+			goto _upDownArrow
+		}
+		continue
 
-		case 3: // Ctrl+c
+	_ctrl:
+		switch key.Rune {
+		case 'c':
 			if err = ln.buf.insertRunes(ctrlC); err != nil {
 				return "", err
 			}
+			if _, err = ln.buf.end(); err != nil {
+				return "", err
+			}
 			if _, err = Output.Write(CRLF); err != nil {
 				return "", outputError(err.Error())
 			}
+			if top := ln.firstRow(); top != 0 {
+				ln.top = top + ln.buf.lastRow + 1
+				if rows, _, err := ln.term.GetSize(); err == nil && ln.top > rows {
+					ln.top = rows
+				}
+			}
 
 			ChanCtrlC <- 1
 
@@ -241,7 +363,7 @@ func (ln *Line) Read() (line string, err error) {
 
 			continue
 
-		case 4: // Ctrl+d
+		case 'd':
 			if err = ln.buf.insertRunes(ctrlD); err != nil {
 				return "", err
 			}
@@ -252,79 +374,22 @@ func (ln *Line) Read() (line string, err error) {
 			ln.Restore()
 			return "", ErrCtrlD
 
-		// Escape sequence
-		case 27: // Escape: Ctrl+[ ("\x1b" in hexadecimal, "033" in octal)
-			if _, err = in.Read(esc); err != nil {
-				return "", inputError(err.Error())
-			}
-
-			if esc[0] == 79 { // 'O'
-				switch esc[1] {
-				case 72: // Home: "\x1b O H"
-					goto _start
-				case 70: // End: "\x1b O F"
-					goto _end
-				}
-			}
+		case 'h':
+			goto _backspace
 
-			if esc[0] == 91 { // '['
-				switch esc[1] {
-				case 68: // "\x1b [ D"
-					goto _leftArrow
-				case 67: // "\x1b [ C"
-					goto _rightArrow
-				case 65, 66: // Up: "\x1b [ A"; Down: "\x1b [ B"
-					goto _upDownArrow
-				}
-
-				// Extended escape.
-				if esc[1] > 48 && esc[1] < 55 {
-					if _, err = in.Read(extEsc); err != nil {
-						return "", inputError(err.Error())
-					}
-
-					if extEsc[0] == 126 { // '~'
-						switch esc[1] {
-						//case 50: // Insert: "\x1b [ 2 ~"
-
-						case 51: // Delete: "\x1b [ 3 ~"
-							if err = ln.buf.deleteChar(); err != nil {
-								return "", err
-							}
-							continue
-							//case 53: // RePag: "\x1b [ 5 ~"
-
-							//case 54: // AvPag: "\x1b [ 6 ~"
-
-						}
-					}
-					if esc[1] == 49 && extEsc[0] == 59 && extEsc[1] == 53 { // "1;5"
-						switch extEsc[2] {
-						case 68: // Ctrl+left arrow: "\x1b [ 1 ; 5 D"
-							// move to last word
-							if err = ln.buf.wordBackward(); err != nil {
-								return "", err
-							}
-							continue
-						case 67: // Ctrl+right arrow: "\x1b [ 1 ; 5 C"
-							// move to next word
-							if err = ln.buf.wordForward(); err != nil {
-								return "", err
-							}
-							continue
-						}
-					}
-				}
+		case 'z':
+			if err = ln.suspend(); err != nil {
+				return "", err
 			}
 			continue
 
-		case 20: // Ctrl+t, swap actual character by the previous one.
+		case 't': // Swap actual character by the previous one.
 			if err = ln.buf.swap(); err != nil {
 				return "", err
 			}
 			continue
 
-		case 21: // Ctrl+u, delete the whole line.
+		case 'u': // Delete the whole line.
 			if err = ln.buf.deleteLine(); err != nil {
 				return "", err
 			}
@@ -333,49 +398,64 @@ func (ln *Line) Read() (line string, err error) {
 			}
 			continue
 
-		case 12: // Ctrl+l, clear screen.
-			if _, err = Output.Write(delScreenToUpper); err != nil {
+		case 'l': // Clear screen.
+			if _, err = io.WriteString(Output, clearToUpper); err != nil {
 				return "", err
 			}
+			if ln.top != 0 {
+				ln.top = 1
+			}
 			if err = ln.Prompt(); err != nil {
 				return "", err
 			}
 			continue
 
-		case 11: // Ctrl+k, delete from current to end of line.
+		case 'k': // Delete from current to end of line.
 			if err = ln.buf.deleteToRight(); err != nil {
 				return "", err
 			}
 			continue
 
-		case 1: // Ctrl+a, go to the start of the line.
+		case 'a': // Go to the start of the line.
 			goto _start
 
-		case 5: // Ctrl+e, go to the end of the line.
+		case 'e': // Go to the end of the line.
 			goto _end
 
-		case 2: // Ctrl+b
+		case 'b':
 			goto _leftArrow
 
-		case 6: // Ctrl+f
+		case 'f':
 			goto _rightArrow
 
-		case 16: // Ctrl+p
-			esc[1] = 65
+		case 'p':
+			key.Key = keys.KeyUp
 			goto _upDownArrow
 
-		case 14: // Ctrl+n
-			esc[1] = 66
+		case 'n':
+			key.Key = keys.KeyDown
 			goto _upDownArrow
 		}
 
+		switch key.Key {
+		case keys.KeyLeft: // Ctrl+left arrow, move to last word.
+			if err = ln.buf.wordBackward(); err != nil {
+				return "", err
+			}
+		case keys.KeyRight: // Ctrl+right arrow, move to next word.
+			if err = ln.buf.wordForward(); err != nil {
+				return "", err
+			}
+		}
+		continue
+
 	_upDownArrow: // Up and down arrow: history
 		if !ln.useHistory {
 			continue
 		}
 
 		// Up
-		if esc[1] == 65 {
+		if key.Key == keys.KeyUp {
 			anotherLine, err = ln.hist.Prev()
 			// Down
 		} else {
@@ -402,6 +482,12 @@ func (ln *Line) Read() (line string, err error) {
 		}
 		continue
 
+	_backspace:
+		if err = ln.buf.deleteCharPrev(); err != nil {
+			return "", err
+		}
+		continue
+
 	_leftArrow:
 		if _, err = ln.buf.backward(); err != nil {
 			return "", err
@@ -426,7 +512,25 @@ func (ln *Line) Read() (line string, err error) {
 		}
 		continue
 	}
-	return
+}
+
+// suspend suspends the process, writing the line again when it is resumed.
+func (ln *Line) suspend() error {
+	if _, err := ln.buf.end(); err != nil {
+		return err
+	}
+	if _, err := Output.Write(ctrlZ); err != nil {
+		return outputError(err.Error())
+	}
+	if _, err := Output.Write(CRLF); err != nil {
+		return outputError(err.Error())
+	}
+
+	if err := ln.term.Suspend(nil); err != nil {
+		return err
+	}
+	ln.top = 0 // the shell has written after of the line
+	return ln.buf.redraw()
 }
 
 // Prompt prints the primary prompt.
@@ -439,11 +543,69 @@ func (ln *Line) Prompt() (err error) {
 	}
 
 	ln.buf.pos, ln.buf.size = ln.lenPS1, ln.lenPS1
+	ln.buf.row, ln.buf.lastRow = 0, 0
 	return
 }
 
+// moveToClick moves the cursor to the position clicked, if the row of the line
+// is known.
+func (ln *Line) moveToClick(x, y int) error {
+	top := ln.firstRow()
+	if top == 0 {
+		return nil
+	}
+	return ln.buf.setPos(ln.buf.xy2pos(y-top, x-1))
+}
+
+// firstRow returns the row of the screen where the line starts, or zero if it
+// is unknown. The screen is scrolled when the rows written reach the bottom.
+func (ln *Line) firstRow() int {
+	if ln.top == 0 {
+		return 0
+	}
+	rows, _, err := ln.term.GetSize()
+	if err == nil && ln.top+ln.buf.lastRow > rows {
+		return rows - ln.buf.lastRow
+	}
+	return ln.top
+}
+
 // == Utility
 
+// lineInput reads the input kept by the terminal after of a query, before of
+// reading from Input.
+type lineInput struct {
+	term *terminal.Terminal
+}
+
+func (in lineInput) Read(p []byte) (int, error) {
+	if in.term.Buffered() != 0 {
+		return in.term.Read(p)
+	}
+	return Input.Read(p)
+}
+
+// termInput reads from the terminal, when it is Input, so the rest of an escape
+// sequence is waited without leaving a read pending after of returning a line.
+type termInput struct {
+	*terminal.Terminal
+}
+
+// newLineInput returns the input of the keys.
+func newLineInput(term *terminal.Terminal) io.Reader {
+	if f, ok := Input.(*os.File); ok && int(f.Fd()) == InputFd {
+		return termInput{term}
+	}
+	return lineInput{term}
+}
+
+// pastedRunes returns the characters of a text pasted, where the line breaks
+// sent by the terminal as "\r" are inserted as "\n".
+func pastedRunes(text string) []rune {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	return []rune(strings.Replace(text, "\r", "\n", -1))
+}
+
 // hasHistory checks whether has an history file.
 func hasHistory(h *history) bool {
 	if h == nil {
@@ -25,6 +25,11 @@ var (
 )
 
 func init() {
+	// The flags of the helper process are the ones of the testing package,
+	// parsed later.
+	if os.Getenv(helperEnv) != "" {
+		return
+	}
 	flag.Parse()
 
 	if *fInteractive {
@@ -88,3 +93,12 @@ _end:
 		t.Fatal(err)
 	}
 }
+
+func TestPastedRunes(t *testing.T) {
+	in := "ls -l\r\necho\ta\x1b[A\rb"
+	out := "ls -l\necho\ta\x1b[A\nb"
+
+	if got := string(pastedRunes(in)); got != out {
+		t.Errorf("expected %q, got %q", out, got)
+	}
+}
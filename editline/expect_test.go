@@ -0,0 +1,139 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !lookup
+
+package editline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kless/terminal/expect"
+	"github.com/kless/terminal/keys"
+)
+
+// The test binary is run in a pseudo-terminal to read lines, when it is set
+// this variable.
+const helperEnv = "EDITLINE_TEST_HELPER"
+
+// TestHelperProcess is not a real test; it reads lines in the process started
+// by TestExpect.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv(helperEnv) == "" {
+		return
+	}
+	Input = os.Stdin
+	InputFd = syscall.Stdin
+
+	ln, err := NewDefaultLine(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for {
+		line, err := ln.Read()
+		if err != nil {
+			ln.Restore()
+			if err == ErrCtrlD {
+				os.Exit(0)
+			}
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(Output, "got %q\r\n", line)
+	}
+}
+
+func TestExpect(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), helperEnv+"=1", "TERM=xterm")
+
+	e, err := expect.Start(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	expectLine := func(want string) {
+		re := regexp.MustCompile(`got "(.*)"`)
+		m, err := e.Expect(re, 5*time.Second)
+		if err != nil {
+			t.Fatalf("%s; output %q", err, e.Output())
+		}
+		if m[1] != want {
+			t.Errorf("expected line %q, got %q", want, m[1])
+		}
+	}
+
+	if _, err = e.ExpectString("$ ", 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	e.SendLine("hello")
+	expectLine("hello")
+
+	e.Send("ab")
+	e.SendKey(keys.KeyEvent{Key: keys.KeyLeft})
+	e.Send("X")
+	e.SendKey(keys.KeyEvent{Key: keys.KeyEnter})
+	expectLine("aXb")
+
+	e.Send("one two")
+	e.SendKey(
+		keys.KeyEvent{Key: keys.KeyLeft, Mod: keys.ModCtrl},
+		keys.KeyEvent{Rune: 'k', Mod: keys.ModCtrl},
+		keys.KeyEvent{Key: keys.KeyEnter},
+	)
+	expectLine("one")
+
+	// A lone Escape is waited through the terminal.
+	e.Send("a\x1b")
+	time.Sleep(300 * time.Millisecond)
+	e.SendLine("b")
+	expectLine("ab")
+
+	// The text pasted is not run until Enter is pressed.
+	e.Send("\033[200~one\rtwo\033[201~")
+	e.SendKey(keys.KeyEvent{Key: keys.KeyEnter})
+	expectLine(`one\ntwo`)
+
+	// The line is written again with the new size.
+	e.Send("hello")
+	if _, err = e.ExpectString("hello", 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	if err = e.SetSize(24, 40); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = e.ExpectString("\r$ hello\033[J", 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	e.SendLine("")
+	expectLine("hello")
+
+	// The process is not stopped since it is not a job of a shell, but the
+	// line is written again.
+	e.Send("ab")
+	e.SendKey(keys.KeyEvent{Rune: 'z', Mod: keys.ModCtrl})
+	if _, err = e.ExpectString("^Z\r\n", 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	if _, err = e.ExpectString("$ ab", 5*time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	e.SendLine("c")
+	expectLine("abc")
+
+	e.SendKey(keys.KeyEvent{Rune: 'd', Mod: keys.ModCtrl})
+	if err = e.Wait(); err != nil {
+		t.Errorf("%s; output %q", err, e.Transcript())
+	}
+}
@@ -33,7 +33,7 @@ func TestLookup(t *testing.T) {
 	}
 	defer term.Restore()
 
-	if err = term.MakeRaw(); err != nil {
+	if err = term.RawMode(); err != nil {
 		t.Error(err)
 	} else {
 		buf := bufio.NewReader(Input)
@@ -0,0 +1,95 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package expect
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kless/terminal/keys"
+)
+
+const script = `
+stty -icanon -echo
+printf 'name? '
+read name
+echo "hello $name"
+stty size
+dd bs=1 count=4 2>/dev/null | od -An -c
+`
+
+func TestExpect(t *testing.T) {
+	e, err := Spawn("sh", "-c", script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	if _, err = e.ExpectString("name? ", 5*time.Second); err != nil {
+		t.Fatalf("prompt: %s; output %q", err, e.Output())
+	}
+	e.SendLine("bob")
+
+	m, err := e.Expect(regexp.MustCompile(`hello (\w+)`), 5*time.Second)
+	if err != nil {
+		t.Fatalf("reply: %s; output %q", err, e.Output())
+	}
+	if m[1] != "bob" {
+		t.Errorf("expected name bob, got %q", m[1])
+	}
+
+	if _, err = e.ExpectString("24 80", 5*time.Second); err != nil {
+		t.Fatalf("size: %s; output %q", err, e.Output())
+	}
+
+	e.SendKey(keys.KeyEvent{Key: keys.KeyUp}, keys.KeyEvent{Rune: 'a'})
+	if _, err = e.Expect(regexp.MustCompile(`033\s+\[\s+A\s+a`), 5*time.Second); err != nil {
+		t.Fatalf("keys: %s; output %q", err, e.Output())
+	}
+
+	if _, err = e.ExpectString("never", 5*time.Second); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+	if err = e.Wait(); err != nil {
+		t.Error(err)
+	}
+
+	tr := e.Transcript()
+	if !strings.HasPrefix(tr, "name? ") || !strings.Contains(tr, "hello bob") {
+		t.Errorf("unexpected transcript: %q", tr)
+	}
+}
+
+func TestExpectTimeout(t *testing.T) {
+	e, err := Spawn("cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	start := time.Now()
+	if _, err = e.ExpectString("foo", 200*time.Millisecond); err != ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if d := time.Since(start); d < 200*time.Millisecond || d > time.Second {
+		t.Errorf("unexpected time waiting: %s", d)
+	}
+
+	// The input is echoed by the terminal.
+	e.SendLine("foo")
+	if _, err = e.ExpectString("foo\r\n", time.Second); err != nil {
+		t.Fatalf("%s; output %q", err, e.Output())
+	}
+	if err = e.SetSize(10, 20); err != nil {
+		t.Error(err)
+	}
+}
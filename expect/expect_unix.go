@@ -0,0 +1,246 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+// Package expect automates interactive programs, running them in a
+// pseudo-terminal to wait for their output and to send them the input, like
+// the program Expect.
+//
+// A session is like:
+//
+//   e, err := expect.Spawn("passwd")
+//   if err != nil {
+//   	return err
+//   }
+//   defer e.Close()
+//
+//   if _, err = e.ExpectString("password: ", 5*time.Second); err != nil {
+//   	return err
+//   }
+//   e.SendLine(pass)
+//
+// The output is matched as it is written by the program, with the escape
+// sequences; the text can be got through ansi.Strip.
+package expect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kless/terminal"
+	"github.com/kless/terminal/keys"
+)
+
+// Size of the pseudo-terminal by default.
+var (
+	DefaultRows    = 24
+	DefaultColumns = 80
+)
+
+// ErrTimeout is returned when the output expected is not got in time.
+var ErrTimeout = errors.New("expect: timeout")
+
+// An Expect represents a program running in a pseudo-terminal.
+type Expect struct {
+	cmd    *exec.Cmd
+	master *os.File
+
+	in  chan []byte // output read in background
+	err error       // error reading, after of consuming the output
+
+	buf []byte // output not matched
+
+	mu         sync.Mutex
+	transcript bytes.Buffer
+
+	waitOnce sync.Once
+	waitErr  error
+}
+
+// Spawn starts the program with the arguments in a pseudo-terminal.
+func Spawn(name string, args ...string) (*Expect, error) {
+	return Start(exec.Command(name, args...))
+}
+
+// Start starts the command in a pseudo-terminal, with the size set in
+// DefaultRows and DefaultColumns. The standard input, output and error of the
+// command which are not set are connected to the pseudo-terminal.
+func Start(cmd *exec.Cmd) (*Expect, error) {
+	master, err := terminal.StartCommandSize(cmd, DefaultRows, DefaultColumns)
+	if err != nil {
+		return nil, fmt.Errorf("expect: could not start command: %s", err)
+	}
+
+	e := &Expect{
+		cmd:    cmd,
+		master: master,
+		in:     make(chan []byte),
+	}
+	go e.read()
+	return e, nil
+}
+
+// read reads the output in background, until it is closed.
+func (e *Expect) read() {
+	for {
+		b := make([]byte, 4096)
+		n, err := e.master.Read(b)
+		if n > 0 {
+			e.mu.Lock()
+			e.transcript.Write(b[:n])
+			e.mu.Unlock()
+			e.in <- b[:n]
+		}
+		if err != nil {
+			// Linux returns EIO when the slave side has been closed.
+			if pe, ok := err.(*os.PathError); ok && pe.Err == syscall.EIO {
+				err = io.EOF
+			}
+			e.err = err
+			close(e.in)
+			return
+		}
+	}
+}
+
+// Expect waits for output matching the regular expression, for the timeout
+// at most. It returns the text matched and its submatches, and the output
+// until the end of the match is consumed.
+//
+// It returns ErrTimeout when the timeout expires, and io.EOF when the output
+// is closed, like when the program exits, before a match.
+func (e *Expect) Expect(re *regexp.Regexp, timeout time.Duration) ([]string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		if loc := re.FindSubmatchIndex(e.buf); loc != nil {
+			match := make([]string, len(loc)/2)
+			for i := range match {
+				if loc[2*i] >= 0 {
+					match[i] = string(e.buf[loc[2*i]:loc[2*i+1]])
+				}
+			}
+			e.buf = e.buf[loc[1]:]
+			return match, nil
+		}
+
+		select {
+		case b, ok := <-e.in:
+			if !ok {
+				return nil, e.err
+			}
+			e.buf = append(e.buf, b...)
+		case <-timer.C:
+			return nil, ErrTimeout
+		}
+	}
+}
+
+// ExpectString waits for output containing the text, like Expect.
+// It returns the output consumed.
+func (e *Expect) ExpectString(s string, timeout time.Duration) (string, error) {
+	m, err := e.Expect(regexp.MustCompile("(?s)^.*?"+regexp.QuoteMeta(s)), timeout)
+	if err != nil {
+		return "", err
+	}
+	return m[0], nil
+}
+
+// Output returns the output got which has not been matched.
+func (e *Expect) Output() string { return string(e.buf) }
+
+// Transcript returns all the output written by the program until now.
+func (e *Expect) Transcript() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.transcript.String()
+}
+
+// Send sends the text to the program, like if it were typed.
+func (e *Expect) Send(s string) error {
+	if _, err := io.WriteString(e.master, s); err != nil {
+		return fmt.Errorf("expect: could not send: %s", err)
+	}
+	return nil
+}
+
+// SendLine sends the text followed by Enter, which is a carriage return.
+func (e *Expect) SendLine(s string) error {
+	return e.Send(s + "\r")
+}
+
+// SendKey sends the keys, like they are sent by a terminal compatible with
+// xterm.
+func (e *Expect) SendKey(key ...keys.KeyEvent) error {
+	var b []byte
+	for _, k := range key {
+		b = append(b, keys.Encode(k)...)
+	}
+	if _, err := e.master.Write(b); err != nil {
+		return fmt.Errorf("expect: could not send: %s", err)
+	}
+	return nil
+}
+
+// SetSize sets the size of the pseudo-terminal. The program gets a SIGWINCH
+// signal.
+func (e *Expect) SetSize(rows, columns int) error {
+	conn, err := e.master.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("expect: could not set size: %s", err)
+	}
+
+	conn.Control(func(fd uintptr) {
+		term, e := terminal.New(int(fd))
+		if e != nil {
+			err = fmt.Errorf("expect: could not set size: %s", e)
+			return
+		}
+		err = term.SetSize(rows, columns, 0, 0)
+	})
+	return err
+}
+
+// Wait waits for the program to exit, consuming its output.
+func (e *Expect) Wait() error {
+	e.waitOnce.Do(func() {
+		for b := range e.in {
+			e.buf = append(e.buf, b...)
+		}
+		e.waitErr = e.cmd.Wait()
+	})
+	return e.waitErr
+}
+
+// Close closes the pseudo-terminal, so the program gets a SIGHUP signal, and
+// waits for it to exit.
+func (e *Expect) Close() error {
+	err := e.master.Close()
+
+	done := make(chan bool)
+	go func() {
+		e.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		e.cmd.Process.Kill()
+		<-done
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// guardHelper guards the terminal of the helper process, and waits for a
+// signal or panics.
+func guardHelper(panics bool) {
+	term, err := New(syscall.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	guard := term.Guard()
+	defer guard.Close()
+
+	if err = term.RawMode(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	term.EnableBracketedPaste()
+	fmt.Print("ready\r\n")
+
+	if panics {
+		panic("boom")
+	}
+	time.Sleep(10 * time.Second)
+}
+
+func testGuard(t *testing.T, action string) {
+	cmd := helperCommand("guard-" + action)
+
+	master, err := StartCommand(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer master.Close()
+
+	slave, err := os.Open(cmd.Stdin.(*os.File).Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slave.Close()
+	term, err := New(int(slave.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The output is read in background, since the slave side is not closed.
+	outc := make(chan []byte)
+	go func() {
+		for {
+			b := make([]byte, 256)
+			n, err := master.Read(b)
+			if n > 0 {
+				outc <- b[:n]
+			}
+			if err != nil {
+				close(outc)
+				return
+			}
+		}
+	}()
+
+	var out bytes.Buffer
+	for !strings.Contains(out.String(), "ready") {
+		select {
+		case b := <-outc:
+			out.Write(b)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout; output %q", out.String())
+		}
+	}
+	if action == "signal" {
+		cmd.Process.Signal(syscall.SIGTERM)
+	}
+	err = cmd.Wait()
+
+	for done := false; !done; {
+		select {
+		case b := <-outc:
+			out.Write(b)
+		case <-time.After(100 * time.Millisecond):
+			done = true
+		}
+	}
+
+	status, ok := err.(*exec.ExitError)
+	if !ok {
+		t.Fatalf("expected exit error, got %v", err)
+	}
+	ws := status.Sys().(syscall.WaitStatus)
+
+	if action == "signal" {
+		if !ws.Signaled() || ws.Signal() != syscall.SIGTERM {
+			t.Errorf("expected to be terminated by SIGTERM, got %s", status)
+		}
+	} else if !strings.Contains(out.String(), "panic: boom") {
+		t.Errorf("expected panic, got output %q", out.String())
+	}
+
+	if !strings.Contains(out.String(), "\033[?2004l") {
+		t.Errorf("expected to reset the modes, got output %q", out.String())
+	}
+	if state, err := term.CurrentState(); err != nil {
+		t.Error(err)
+	} else if state.wrap.Lflag&ICANON == 0 {
+		t.Error("expected to restore the terminal state")
+	}
+}
+
+func TestGuardSignal(t *testing.T) { testGuard(t, "signal") }
+func TestGuardPanic(t *testing.T)  { testGuard(t, "panic") }
@@ -0,0 +1,113 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// guardSignals are the signals which terminate the process by default, caught
+// by a guard.
+var guardSignals = []os.Signal{
+	syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT,
+}
+
+// A Guard restores the terminal when the process is terminated by a signal,
+// or by a panic, so the shell is not left in raw mode or with the modes set
+// through escape sequences.
+//
+// When it gets SIGINT, SIGTERM, SIGHUP or SIGQUIT, the terminal is restored,
+// and the signal is raised again with the default action, which terminates the
+// process. The signals ignored when the guard is created, like SIGHUP under
+// nohup, are not caught.
+//
+// Note that the signals are caught by the guard although the program gets them
+// too through signal.Notify, so it is not fit for a program which handles them.
+type Guard struct {
+	term *Terminal
+	sig  chan os.Signal
+	stop chan bool
+
+	restoreOnce sync.Once
+	stopOnce    sync.Once
+}
+
+// Guard starts a guard of the terminal, which has to be closed in the
+// goroutine which uses the terminal, to restore it at panicking:
+//
+//   term, err := terminal.New(syscall.Stdin)
+//   if err != nil {
+//   	return err
+//   }
+//   guard := term.Guard()
+//   defer guard.Close()
+//
+//   if err = term.RawMode(); err != nil {
+//   	return err
+//   }
+//   defer term.Restore()
+func (t *Terminal) Guard() *Guard {
+	g := &Guard{
+		term: t,
+		sig:  make(chan os.Signal, 1),
+		stop: make(chan bool),
+	}
+
+	var sigs []os.Signal
+	for _, s := range guardSignals {
+		if !signal.Ignored(s) {
+			sigs = append(sigs, s)
+		}
+	}
+	signal.Notify(g.sig, sigs...)
+
+	go g.wait()
+	return g
+}
+
+// wait waits for a signal to restore the terminal and raise it again, until the
+// guard is closed.
+func (g *Guard) wait() {
+	select {
+	case s := <-g.sig:
+		g.restore()
+
+		signal.Reset(s)
+		syscall.Kill(syscall.Getpid(), s.(syscall.Signal))
+	case <-g.stop:
+	}
+}
+
+// restore restores the original state of the terminal, and resets the modes
+// set through escape sequences.
+func (g *Guard) restore() {
+	g.restoreOnce.Do(func() {
+		t := g.term
+
+		t.Restore()
+		tcsetattr(t.fd, _TCSANOW, &t.oldState)
+	})
+}
+
+// Close stops the guard. When it is deferred, it recovers a panic of the
+// goroutine to restore the terminal, and then panics again with the same value.
+func (g *Guard) Close() {
+	g.stopOnce.Do(func() {
+		signal.Stop(g.sig)
+		close(g.stop)
+	})
+
+	if r := recover(); r != nil {
+		g.restore()
+		panic(r)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// The test binary is run as a helper process, like in a pseudo-terminal, to do
+// the action set in this variable.
+const helperEnv = "TERMINAL_TEST_HELPER"
+
+// helperCommand returns the command to run the test binary as a helper process
+// which does the action.
+func helperCommand(action string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), helperEnv+"="+action)
+	return cmd
+}
+
+// TestHelperProcess is not a real test; it does the action of the helper
+// process started through helperCommand.
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv(helperEnv) {
+	case "":
+		return
+	case "guard-signal":
+		guardHelper(false)
+	case "guard-panic":
+		guardHelper(true)
+	case "job":
+		jobHelper()
+	}
+	os.Exit(0)
+}
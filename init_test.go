@@ -13,6 +13,7 @@ import (
 	"io"
 	"os"
 	"syscall"
+	"testing"
 )
 
 var (
@@ -26,7 +27,9 @@ var (
 	INPUT_FD = syscall.Stderr
 )
 
-func init() {
+// TestMain parses the flags once the ones of the testing package have been
+// defined, like "-test.run" used to run the helper process.
+func TestMain(m *testing.M) {
 	flag.Parse()
 
 	if *fInteractive {
@@ -35,4 +38,5 @@ func init() {
 	} else {
 		INPUT, OUTPUT = io.Pipe()
 	}
+	os.Exit(m.Run())
 }
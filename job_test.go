@@ -0,0 +1,100 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// jobHelper suspends the helper process, run as a job of a shell.
+func jobHelper() {
+	err := func() error {
+		term, err := New(syscall.Stdin)
+		if err != nil {
+			return err
+		}
+		if err = term.RawMode(); err != nil {
+			return err
+		}
+		defer term.Restore()
+		if err = term.EnableBracketedPaste(); err != nil {
+			return err
+		}
+		fmt.Print("ready\r\n")
+
+		if err = term.Suspend(func() { fmt.Print("resumed\r\n") }); err != nil {
+			return err
+		}
+
+		var st termios
+		if err = tcgetattr(term.fd, &st); err != nil {
+			return err
+		}
+		if st.Lflag&ICANON == 0 {
+			fmt.Print("raw\r\n")
+		}
+		return nil
+	}()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func TestSuspend(t *testing.T) {
+	if _, err := exec.LookPath("stty"); err != nil {
+		t.Skip("stty not found")
+	}
+
+	// The shell with job control reports the terminal settings while the job
+	// is stopped, and continues it.
+	helper := helperCommand("job")
+	cmd := exec.Command("sh", "-m", "-c", `"$0" "$1"; stty -a | grep -o -- " -\?icanon"; fg`,
+		helper.Args[0], helper.Args[1])
+	cmd.Env = helper.Env
+
+	master, err := StartCommand(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer master.Close()
+
+	done := make(chan bool)
+	var out bytes.Buffer
+	go func() {
+		io.Copy(&out, master) // until the shell exits
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		master.Close()
+		<-done
+		t.Fatalf("timeout; output %q", out.String())
+	}
+	if err = cmd.Wait(); err != nil {
+		t.Errorf("%s; output %q", err, out.String())
+	}
+
+	re := regexp.MustCompile(`(?s)ready\r\n` + regexp.QuoteMeta("\033[?2004l") + `.* icanon.*` +
+		regexp.QuoteMeta("\033[?2004h") + `resumed\r\nraw\r\n`)
+	if !re.MatchString(out.String()) {
+		t.Errorf("expected to restore the terminal while stopped, got output %q", out.String())
+	}
+}
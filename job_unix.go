@@ -0,0 +1,166 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kless/terminal/ansi"
+)
+
+// Suspend stops the process like Ctrl+Z does in a shell with job control,
+// restoring first the terminal. In raw mode the terminal does not send SIGTSTP
+// at pressing Ctrl+Z, so it is got like a key and this function has to be
+// called.
+//
+// When the process is continued, through "fg" or SIGCONT, the terminal is set
+// again as it was: the settings like the raw mode, the DEC private modes like
+// the alternate screen, and the cursor hidden. Then, redraw is called if it is
+// not nil, to draw the screen again.
+//
+// It does nothing when SIGTSTP is ignored, like in a shell without job control.
+func (t *Terminal) Suspend(redraw func()) error {
+	if signal.Ignored(syscall.SIGTSTP) {
+		return nil
+	}
+
+	state, mod := t.lastState, t.mod
+	modes := append([]ansi.Mode(nil), t.privModes...)
+	hidden := t.cursorHidden
+
+	if err := t.Restore(); err != nil {
+		return err
+	}
+
+	// The process could be stopped after of returning from kill, when the
+	// signal is got by another thread, so it is waited to be continued.
+	cont := make(chan os.Signal, 1)
+	signal.Notify(cont, syscall.SIGCONT)
+	defer signal.Stop(cont)
+
+	// The default action of SIGTSTP stops the process, so the signal is not
+	// caught while it is sent to the process group, like the terminal does.
+	t.jobMu.Lock()
+	signal.Reset(syscall.SIGTSTP)
+	err := syscall.Kill(0, syscall.SIGTSTP)
+	if err == nil {
+		waitContinue(cont)
+	}
+	if t.jobs != nil {
+		signal.Notify(t.jobs.sig, syscall.SIGTSTP)
+	}
+	t.jobMu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("terminal: could not suspend: %s", err)
+	}
+
+	// == Continued
+	if mod != 0 {
+		if err = tcsetattr(t.fd, _TCSANOW, &state); err != nil {
+			return fmt.Errorf("terminal: could not resume: %s", err)
+		}
+		t.lastState, t.mod = state, mod
+	}
+	if len(modes) != 0 {
+		if err = t.setPrivateModes(true, modes...); err != nil {
+			return fmt.Errorf("terminal: could not resume: %s", err)
+		}
+	}
+	if hidden {
+		if err = t.HideCursor(); err != nil {
+			return err
+		}
+	}
+
+	if redraw != nil {
+		redraw()
+	}
+	return nil
+}
+
+// continueTimeout is the time waited for SIGCONT after of sending SIGTSTP,
+// which is discarded by the system in an orphaned process group.
+const continueTimeout = time.Second
+
+// waitContinue waits until the process is continued, or the signal to stop it
+// has been discarded.
+func waitContinue(cont <-chan os.Signal) {
+	timer := time.NewTimer(continueTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-cont:
+	case <-timer.C:
+	}
+}
+
+// A JobControl suspends the process when it gets SIGTSTP.
+type JobControl struct {
+	term *Terminal
+	sig  chan os.Signal
+	stop chan bool
+	once sync.Once
+}
+
+// JobControl starts to catch SIGTSTP to suspend the process through Suspend,
+// with the function redraw. The signal is sent by the terminal at pressing
+// Ctrl+Z when the raw mode is not set, or by another process like through
+// "kill -TSTP".
+//
+// Note that redraw is called in another goroutine. The signal is not caught if
+// it is ignored, like in a shell without job control.
+func (t *Terminal) JobControl(redraw func()) *JobControl {
+	j := &JobControl{
+		term: t,
+		sig:  make(chan os.Signal, 1),
+		stop: make(chan bool),
+	}
+	if signal.Ignored(syscall.SIGTSTP) {
+		return j
+	}
+
+	t.jobMu.Lock()
+	t.jobs = j
+	signal.Notify(j.sig, syscall.SIGTSTP)
+	t.jobMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case <-j.sig:
+				t.Suspend(redraw)
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+	return j
+}
+
+// Close stops catching SIGTSTP.
+func (j *JobControl) Close() {
+	j.once.Do(func() {
+		t := j.term
+
+		t.jobMu.Lock()
+		if t.jobs == j {
+			t.jobs = nil
+		}
+		signal.Stop(j.sig)
+		t.jobMu.Unlock()
+
+		close(j.stop)
+	})
+}
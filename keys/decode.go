@@ -0,0 +1,501 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// The references about the escape sequences sent by the keys have been got
+// from http://invisible-island.net/xterm/ctlseqs/ctlseqs.html and the manual
+// pages of rxvt and console_codes.
+
+package keys
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultEscTimeout is the time to wait by default for the rest of an escape
+// sequence, after of an Escape.
+const DefaultEscTimeout = 50 * time.Millisecond
+
+const esc = 27
+
+// A TimeoutReader is a reader whose reads can wait for the input a limited
+// time, like a terminal through the settings VMIN and VTIME.
+type TimeoutReader interface {
+	io.Reader
+
+	// ReadTimeout is like Read, but it returns zero bytes and no error when
+	// there is no input after of the timeout.
+	ReadTimeout(p []byte, timeout time.Duration) (n int, err error)
+}
+
+// errClosed is returned at reading from a decoder closed.
+var errClosed = errors.New("keys: decoder closed")
+
+// A Decoder reads and decodes key events from an input stream.
+//
+// The input is read when an event is requested, so the stream can be read by
+// others between calls, i.e. to get the reply of a query to the terminal.
+//
+// If the stream is a TimeoutReader, the rest of an escape sequence is waited
+// through ReadTimeout. Else, the input is read in background, and after of a
+// lone Escape there is a read requested which gets the next input; then, the
+// decoder has to be closed to stop the reading.
+type Decoder struct {
+	// EscTimeout is the time to wait for the rest of an escape sequence.
+	// When it expires, the bytes read are decoded as they are: a lone Escape
+	// or Alt+key.
+	EscTimeout time.Duration
+
+	r       io.Reader
+	req     chan bool // requests to read
+	in      chan input
+	reading bool // a read has been requested
+	closed  bool
+	buf     []byte
+	err     error // error from the input, once the buffer has been consumed
+}
+
+// input represents the data read in background.
+type input struct {
+	data []byte
+	err  error
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{EscTimeout: DefaultEscTimeout, r: r}
+}
+
+// ReadKey reads the next key event, skipping the rest of events.
+func (d *Decoder) ReadKey() (KeyEvent, error) {
+	for {
+		ev, err := d.ReadEvent()
+		if err != nil {
+			return KeyEvent{}, err
+		}
+		if key, ok := ev.(KeyEvent); ok {
+			return key, nil
+		}
+	}
+}
+
+// ReadEvent reads the next event.
+func (d *Decoder) ReadEvent() (Event, error) {
+	if d.closed {
+		return nil, errClosed
+	}
+	tr, timeout := d.r.(TimeoutReader)
+
+	for {
+		if len(d.buf) != 0 {
+			if ev, n := DecodeEvent(d.buf, false); n != 0 {
+				d.buf = d.buf[n:]
+				return ev, nil
+			}
+		}
+
+		if d.err != nil {
+			if len(d.buf) != 0 {
+				ev, n := DecodeEvent(d.buf, true)
+				d.buf = d.buf[n:]
+				return ev, nil
+			}
+			return nil, d.err
+		}
+
+		// A pasted text is waited until its end.
+		wait := len(d.buf) == 0 || bytes.HasPrefix(d.buf, pasteStart)
+
+		if timeout {
+			b := make([]byte, 128)
+			var n int
+			var err error
+
+			if wait {
+				n, err = d.r.Read(b)
+			} else if n, err = tr.ReadTimeout(b, d.EscTimeout); n == 0 && err == nil {
+				ev, n := DecodeEvent(d.buf, true)
+				d.buf = d.buf[n:]
+				return ev, nil
+			}
+			d.receive(input{b[:n], err})
+			continue
+		}
+
+		if d.in == nil {
+			d.req = make(chan bool)
+			d.in = make(chan input, 1) // to not block a read after of closing
+			go d.read()
+		}
+		if !d.reading {
+			d.req <- true
+			d.reading = true
+		}
+
+		if wait {
+			d.receive(<-d.in)
+			continue
+		}
+
+		// Wait for the rest of an incomplete sequence.
+		timer := time.NewTimer(d.EscTimeout)
+		select {
+		case in := <-d.in:
+			timer.Stop()
+			d.receive(in)
+		case <-timer.C:
+			ev, n := DecodeEvent(d.buf, true)
+			d.buf = d.buf[n:]
+			return ev, nil
+		}
+	}
+}
+
+// read reads the input in background, at each request.
+func (d *Decoder) read() {
+	for _ = range d.req {
+		b := make([]byte, 128)
+		n, err := d.r.Read(b)
+		for n == 0 && err == nil {
+			n, err = d.r.Read(b)
+		}
+
+		d.in <- input{b[:n], err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (d *Decoder) receive(in input) {
+	if in.data != nil {
+		d.buf = append(d.buf, in.data...)
+	}
+	d.err = in.err
+	d.reading = false
+}
+
+// Close stops the reading in background, if any. Note that a read already
+// requested is not stopped, so the input got by it is lost.
+func (d *Decoder) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	if d.req != nil {
+		close(d.req)
+	}
+	return nil
+}
+
+// Decode decodes the first key event in p, and returns it together with the
+// number of bytes used. The events which are not of keys are decoded as
+// KeyUnknown.
+//
+// If p holds the start of an escape sequence or of an UTF-8 character, then n
+// is zero, unless flush is set; then, the bytes are decoded as they are.
+func Decode(p []byte, flush bool) (KeyEvent, int) {
+	ev, n := DecodeEvent(p, flush)
+	if key, ok := ev.(KeyEvent); ok || n == 0 {
+		return key, n
+	}
+	return KeyEvent{Key: KeyUnknown}, n
+}
+
+// DecodeEvent decodes the first event in p, like Decode.
+func DecodeEvent(p []byte, flush bool) (Event, int) {
+	if bytes.HasPrefix(p, pasteStart) {
+		if ev, n := decodePaste(p, flush); n != 0 {
+			return ev, n
+		}
+		return nil, 0
+	}
+	if len(p) > 2 && p[0] == esc && p[1] == '[' {
+		if ev, n, ok := decodeMouse(p); ok && (n != 0 || !flush) {
+			if n == 0 {
+				return nil, 0
+			}
+			return ev, n
+		}
+	}
+
+	if ev, n := decodeKey(p, flush); n != 0 {
+		return ev, n
+	}
+	return nil, 0
+}
+
+// decodeKey decodes the first key event in p.
+func decodeKey(p []byte, flush bool) (ev KeyEvent, n int) {
+	if len(p) == 0 {
+		return
+	}
+	if p[0] != esc {
+		return decodeChar(p, flush)
+	}
+
+	if len(p) == 1 {
+		if flush {
+			return KeyEvent{Key: KeyEscape}, 1
+		}
+		return
+	}
+
+	switch p[1] {
+	case '[':
+		if ev, n = decodeCSI(p); n != 0 || !flush {
+			return
+		}
+	case 'O':
+		if ev, n = decodeSS3(p); n != 0 || !flush {
+			return
+		}
+	case esc: // Alt with an escape sequence.
+		if ev, n = decodeKey(p[1:], flush); n == 0 {
+			return
+		}
+		ev.Mod |= ModAlt
+		return ev, n + 1
+	}
+
+	// Alt+key
+	if ev, n = decodeChar(p[1:], flush); n == 0 {
+		return
+	}
+	ev.Mod |= ModAlt
+	return ev, n + 1
+}
+
+// decodeChar decodes a character, which is not part of an escape sequence.
+func decodeChar(p []byte, flush bool) (KeyEvent, int) {
+	c := p[0]
+
+	switch {
+	case c == 13:
+		return KeyEvent{Key: KeyEnter}, 1
+	case c == 9:
+		return KeyEvent{Key: KeyTab}, 1
+	case c == 127:
+		return KeyEvent{Key: KeyBackspace}, 1
+	case c == 27:
+		return KeyEvent{Key: KeyEscape}, 1
+	case c == 0: // Ctrl+Space
+		return KeyEvent{Rune: ' ', Mod: ModCtrl}, 1
+	case c < 27: // Ctrl+a to Ctrl+z
+		return KeyEvent{Rune: rune('a' + c - 1), Mod: ModCtrl}, 1
+	case c < 32: // Ctrl+\, Ctrl+], Ctrl+^, Ctrl+_
+		return KeyEvent{Rune: rune('@' + c), Mod: ModCtrl}, 1
+	case c < utf8.RuneSelf:
+		return KeyEvent{Rune: rune(c)}, 1
+	}
+
+	if !utf8.FullRune(p) && !flush {
+		return KeyEvent{}, 0
+	}
+	r, n := utf8.DecodeRune(p)
+	return KeyEvent{Rune: r}, n
+}
+
+// decodeCSI decodes a sequence started by the Control Sequence Introducer,
+// "ESC [".
+func decodeCSI(p []byte) (KeyEvent, int) {
+	// Linux console: F1 to F5.
+	if len(p) > 2 && p[2] == '[' {
+		if len(p) == 3 {
+			return KeyEvent{}, 0
+		}
+		if p[3] >= 'A' && p[3] <= 'E' {
+			return KeyEvent{Key: KeyF1 + Key(p[3]-'A')}, 4
+		}
+		return KeyEvent{Key: KeyUnknown}, 4
+	}
+
+	// Parameters, intermediate bytes and final byte.
+	i := 2
+	for ; i < len(p) && p[i] >= 0x30 && p[i] <= 0x3F; i++ {
+	}
+	params := p[2:i]
+
+	// rxvt modifiers, in the keys sent like "ESC [ 2 ~"; not in a sequence
+	// with the intermediate byte '$', like "ESC [ ? 1 ; 2 $ y".
+	if i < len(p) && (p[i] == '$' || p[i] == '^') && isDigits(params) {
+		return decodeTilde(params, p[i]), i + 1
+	}
+	for ; i < len(p) && p[i] >= 0x20 && p[i] <= 0x2F; i++ {
+	}
+	if i == len(p) {
+		return KeyEvent{}, 0
+	}
+	final := p[i]
+	n := i + 1
+
+	if final < 0x40 || final > 0x7E {
+		return KeyEvent{Key: KeyUnknown}, i
+	}
+	if i != 2+len(params) || len(params) != 0 && params[0] >= '<' {
+		return KeyEvent{Key: KeyUnknown}, n // intermediate bytes or private
+	}
+
+	switch final {
+	case '~':
+		return decodeTilde(params, final), n
+	case '@': // rxvt Ctrl+Shift
+		return decodeTilde(params, final), n
+	case 'Z':
+		return KeyEvent{Key: KeyTab, Mod: ModShift}, n
+	case 'u':
+		code, mod := parseParams(params)
+		return decodeCodepoint(code, mod), n
+	}
+
+	_, mod := parseParams(params)
+	return withMod(finalKey(final), mod), n
+}
+
+// decodeSS3 decodes a sequence started by the Single Shift Three, "ESC O".
+func decodeSS3(p []byte) (KeyEvent, int) {
+	i := 2
+	for ; i < len(p) && p[i] >= '0' && p[i] <= '9'; i++ {
+	}
+	if i == len(p) {
+		return KeyEvent{}, 0
+	}
+
+	var mod int
+	if i != 2 {
+		mod = atoi(p[2:i])
+	}
+	if p[i] == 'M' { // Enter in the keypad.
+		return withMod(KeyEvent{Key: KeyEnter}, mod), i + 1
+	}
+	return withMod(finalKey(p[i]), mod), i + 1
+}
+
+// finalKey returns the key of a sequence identified by its final byte.
+func finalKey(final byte) KeyEvent {
+	switch final {
+	case 'A':
+		return KeyEvent{Key: KeyUp}
+	case 'B':
+		return KeyEvent{Key: KeyDown}
+	case 'C':
+		return KeyEvent{Key: KeyRight}
+	case 'D':
+		return KeyEvent{Key: KeyLeft}
+	case 'H':
+		return KeyEvent{Key: KeyHome}
+	case 'F':
+		return KeyEvent{Key: KeyEnd}
+	case 'P', 'Q', 'R', 'S':
+		return KeyEvent{Key: KeyF1 + Key(final-'P')}
+	}
+	return KeyEvent{Key: KeyUnknown}
+}
+
+// tildeKeys are the keys sent as "ESC [ code ~", in VT220 format.
+var tildeKeys = map[int]Key{
+	1: KeyHome, 2: KeyInsert, 3: KeyDelete, 4: KeyEnd, 5: KeyPageUp,
+	6: KeyPageDown, 7: KeyHome, 8: KeyEnd,
+
+	11: KeyF1, 12: KeyF2, 13: KeyF3, 14: KeyF4, 15: KeyF5,
+	17: KeyF6, 18: KeyF7, 19: KeyF8, 20: KeyF9, 21: KeyF10,
+	23: KeyF11, 24: KeyF12, 25: KeyF13, 26: KeyF14, 28: KeyF15,
+	29: KeyF16, 31: KeyF17, 32: KeyF18, 33: KeyF19, 34: KeyF20,
+}
+
+// decodeTilde decodes the keys in VT220 format, whose modifiers are set in the
+// parameters (xterm) or in the final byte (rxvt).
+func decodeTilde(params []byte, final byte) KeyEvent {
+	code, mod := parseParams(params)
+
+	key, ok := tildeKeys[code]
+	if !ok {
+		return KeyEvent{Key: KeyUnknown}
+	}
+	ev := KeyEvent{Key: key}
+
+	switch final {
+	case '$':
+		ev.Mod = ModShift
+	case '^':
+		ev.Mod = ModCtrl
+	case '@':
+		ev.Mod = ModCtrl | ModShift
+	default:
+		return withMod(ev, mod)
+	}
+	return ev
+}
+
+// decodeCodepoint decodes a key sent as "ESC [ codepoint ; modifiers u".
+func decodeCodepoint(code, mod int) KeyEvent {
+	switch code {
+	case 13:
+		return withMod(KeyEvent{Key: KeyEnter}, mod)
+	case 9:
+		return withMod(KeyEvent{Key: KeyTab}, mod)
+	case 127:
+		return withMod(KeyEvent{Key: KeyBackspace}, mod)
+	case 27:
+		return withMod(KeyEvent{Key: KeyEscape}, mod)
+	}
+	if code < 32 || !utf8.ValidRune(rune(code)) {
+		return KeyEvent{Key: KeyUnknown}
+	}
+	return withMod(KeyEvent{Rune: rune(code)}, mod)
+}
+
+// withMod adds the modifiers in xterm format, which is 1 plus the bits of
+// Shift (1), Alt (2), Ctrl (4) and Meta (8).
+//
+// The function keys F13 to F24 are sent by xterm like F1 to F12 with Shift.
+func withMod(ev KeyEvent, mod int) KeyEvent {
+	if mod < 2 || ev.Key == KeyUnknown {
+		return ev
+	}
+	ev.Mod = Mod(mod-1) & (ModShift | ModAlt | ModCtrl | ModMeta)
+
+	if ev.Key >= KeyF1 && ev.Key <= KeyF12 && ev.Mod&ModShift != 0 {
+		ev.Key += 12
+		ev.Mod &^= ModShift
+	}
+	return ev
+}
+
+// parseParams returns the first two numeric parameters of a sequence.
+func parseParams(params []byte) (p1, p2 int) {
+	for i, c := range params {
+		if c == ';' {
+			return atoi(params[:i]), atoi(params[i+1:])
+		}
+	}
+	return atoi(params), 0
+}
+
+// isDigits reports whether b is a number.
+func isDigits(b []byte) bool {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return len(b) != 0
+}
+
+// atoi returns the number at the start of b.
+func atoi(b []byte) (n int) {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		n = n*10 + int(c-'0')
+	}
+	return
+}
@@ -0,0 +1,244 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package keys
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+var decodeTests = []struct {
+	in string
+	ev KeyEvent
+	n  int
+}{
+	{"a", KeyEvent{Rune: 'a'}, 1},
+	{"€x", KeyEvent{Rune: '€'}, 3},
+	{"\r", KeyEvent{Key: KeyEnter}, 1},
+	{"\t", KeyEvent{Key: KeyTab}, 1},
+	{"\x7f", KeyEvent{Key: KeyBackspace}, 1},
+	{"\x01", KeyEvent{Rune: 'a', Mod: ModCtrl}, 1},
+	{"\x1c", KeyEvent{Rune: '\\', Mod: ModCtrl}, 1},
+
+	{"\x1b[A", KeyEvent{Key: KeyUp}, 3},
+	{"\x1bOD", KeyEvent{Key: KeyLeft}, 3},
+	{"\x1bOH", KeyEvent{Key: KeyHome}, 3},
+	{"\x1b[F", KeyEvent{Key: KeyEnd}, 3},
+	{"\x1b[1;5C", KeyEvent{Key: KeyRight, Mod: ModCtrl}, 6},
+	{"\x1b[1;3D", KeyEvent{Key: KeyLeft, Mod: ModAlt}, 6},
+	{"\x1b[1;10A", KeyEvent{Key: KeyUp, Mod: ModShift | ModMeta}, 7},
+	{"\x1b[3~", KeyEvent{Key: KeyDelete}, 4},
+	{"\x1b[2~", KeyEvent{Key: KeyInsert}, 4},
+	{"\x1b[5;5~", KeyEvent{Key: KeyPageUp, Mod: ModCtrl}, 6},
+	{"\x1b[6~", KeyEvent{Key: KeyPageDown}, 4},
+	{"\x1b[Z", KeyEvent{Key: KeyTab, Mod: ModShift}, 3},
+
+	{"\x1bOP", KeyEvent{Key: KeyF1}, 3},
+	{"\x1b[[E", KeyEvent{Key: KeyF5}, 4},
+	{"\x1b[15~", KeyEvent{Key: KeyF5}, 5},
+	{"\x1b[24~", KeyEvent{Key: KeyF12}, 5},
+	{"\x1b[34~", KeyEvent{Key: KeyF20}, 5},
+	{"\x1b[1;2P", KeyEvent{Key: KeyF13}, 6},
+	{"\x1b[24;2~", KeyEvent{Key: KeyF24}, 7},
+	{"\x1b[24;6~", KeyEvent{Key: KeyF24, Mod: ModCtrl}, 7},
+	{"\x1b[2$", KeyEvent{Key: KeyInsert, Mod: ModShift}, 4},
+	{"\x1b[5^", KeyEvent{Key: KeyPageUp, Mod: ModCtrl}, 4},
+	{"\x1b[97;5u", KeyEvent{Rune: 'a', Mod: ModCtrl}, 7},
+
+	{"\x1bb", KeyEvent{Rune: 'b', Mod: ModAlt}, 2},
+	{"\x1b\x1b[A", KeyEvent{Key: KeyUp, Mod: ModAlt}, 4},
+	{"\x1b[?1;2c", KeyEvent{Key: KeyUnknown}, 7},
+	{"\x1b[?2004;1$y", KeyEvent{Key: KeyUnknown}, 11},
+	{"\x1b[4;2$y", KeyEvent{Key: KeyUnknown}, 7},
+
+	// Incomplete
+	{"\x1b", KeyEvent{}, 0},
+	{"\x1b[", KeyEvent{}, 0},
+	{"\x1b[1;5", KeyEvent{}, 0},
+	{"\x1bO", KeyEvent{}, 0},
+	{"\xe2\x82", KeyEvent{}, 0},
+}
+
+func TestDecode(t *testing.T) {
+	for _, tt := range decodeTests {
+		ev, n := Decode([]byte(tt.in), false)
+		if ev != tt.ev || n != tt.n {
+			t.Errorf("Decode(%q) = %v, %d; want %v, %d", tt.in, ev, n, tt.ev, tt.n)
+		}
+	}
+
+	flushTests := []struct {
+		in string
+		ev KeyEvent
+		n  int
+	}{
+		{"\x1b", KeyEvent{Key: KeyEscape}, 1},
+		{"\x1b[", KeyEvent{Rune: '[', Mod: ModAlt}, 2},
+		{"\x1bO", KeyEvent{Rune: 'O', Mod: ModAlt}, 2},
+		{"\x1b\x1b", KeyEvent{Key: KeyEscape, Mod: ModAlt}, 2},
+	}
+	for _, tt := range flushTests {
+		ev, n := Decode([]byte(tt.in), true)
+		if ev != tt.ev || n != tt.n {
+			t.Errorf("Decode(%q, flush) = %v, %d; want %v, %d", tt.in, ev, n, tt.ev, tt.n)
+		}
+	}
+}
+
+var eventTests = []struct {
+	in string
+	ev Event
+	n  int
+}{
+	{"\x1b[M !!", MouseEvent{X: 1, Y: 1, Button: ButtonLeft}, 6},
+	{"\x1b[M#+*", MouseEvent{X: 11, Y: 10, Action: MouseRelease}, 6},
+	{"\x1b[M`!!", MouseEvent{X: 1, Y: 1, Button: WheelUp}, 6},
+	{"\x1b[<0;300;120M", MouseEvent{X: 300, Y: 120, Button: ButtonLeft}, 13},
+	{"\x1b[<2;5;7m", MouseEvent{X: 5, Y: 7, Button: ButtonRight, Action: MouseRelease}, 9},
+	{"\x1b[<32;5;7M", MouseEvent{X: 5, Y: 7, Button: ButtonLeft, Action: MouseMotion}, 10},
+	{"\x1b[<35;5;7M", MouseEvent{X: 5, Y: 7, Action: MouseMotion}, 10},
+	{"\x1b[<17;5;7M", MouseEvent{X: 5, Y: 7, Button: ButtonMiddle, Mods: ModCtrl}, 10},
+	{"\x1b[<65;1;2M", MouseEvent{X: 1, Y: 2, Button: WheelDown}, 10},
+	{"\x1b[<128;1;2M", MouseEvent{X: 1, Y: 2, Button: Button8}, 11},
+	{"\x1b[32;250;3M", MouseEvent{X: 250, Y: 3, Button: ButtonLeft}, 11},
+	{"\x1b[1;5A", KeyEvent{Key: KeyUp, Mod: ModCtrl}, 6},
+
+	{"\x1b[200~ls\r\x1b[A\x1b[201~x", PasteEvent{"ls\r\x1b[A"}, 18},
+	{"\x1b[200~\x1b[201~", PasteEvent{""}, 12},
+
+	// Incomplete
+	{"\x1b[200~ls\r", nil, 0},
+	{"\x1b[M !", nil, 0},
+	{"\x1b[<0;30", nil, 0},
+	{"\x1b[32;250", nil, 0},
+}
+
+func TestDecodeEvent(t *testing.T) {
+	for _, tt := range eventTests {
+		ev, n := DecodeEvent([]byte(tt.in), false)
+		if ev != tt.ev || n != tt.n {
+			t.Errorf("DecodeEvent(%q) = %v, %d; want %v, %d", tt.in, ev, n, tt.ev, tt.n)
+		}
+	}
+
+	if ev, n := DecodeEvent([]byte("\x1b[200~ls"), true); ev != (PasteEvent{"ls"}) || n != 8 {
+		t.Errorf("expected an incomplete paste at flush, got %v, %d", ev, n)
+	}
+	if ev, n := Decode([]byte("\x1b[<0;1;1M"), false); ev.Key != KeyUnknown || n != 9 {
+		t.Errorf("expected a report of the mouse as unknown key, got %v, %d", ev, n)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	r, w := io.Pipe()
+	dec := NewDecoder(r)
+	dec.EscTimeout = 20 * time.Millisecond
+
+	go func() {
+		w.Write([]byte("x\x1b[<0;3;4M"))
+		w.Write([]byte("\x1b[1;5"))
+		w.Write([]byte("D"))
+		w.Write([]byte("\x1b"))
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("\x1b"))
+		w.Write([]byte("[B"))
+		w.Write([]byte("\x1b[200~a\x1b"))
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("b\x1b[201~"))
+		w.Close()
+	}()
+
+	want := []KeyEvent{
+		{Rune: 'x'},
+		{Key: KeyLeft, Mod: ModCtrl},
+		{Key: KeyEscape},
+		{Key: KeyDown},
+	}
+	for _, ev := range want {
+		got, err := dec.ReadKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != ev {
+			t.Errorf("expected %v, got %v", ev, got)
+		}
+	}
+
+	ev, err := dec.ReadEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev != (PasteEvent{"a\x1bb"}) {
+		t.Errorf("expected paste, got %v", ev)
+	}
+	if _, err := dec.ReadKey(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+// timeoutReader is a TimeoutReader which gets the input from a channel.
+type timeoutReader chan []byte
+
+func (r timeoutReader) Read(p []byte) (int, error) {
+	return copy(p, <-r), nil
+}
+
+func (r timeoutReader) ReadTimeout(p []byte, timeout time.Duration) (int, error) {
+	select {
+	case b := <-r:
+		return copy(p, b), nil
+	case <-time.After(timeout):
+		return 0, nil
+	}
+}
+
+func TestDecoderTimeout(t *testing.T) {
+	r := make(timeoutReader)
+	dec := NewDecoder(r)
+	dec.EscTimeout = 20 * time.Millisecond
+
+	go func() {
+		r <- []byte("\x1b[1;5")
+		r <- []byte("D")
+		r <- []byte("\x1b")
+	}()
+
+	for _, ev := range []KeyEvent{{Key: KeyLeft, Mod: ModCtrl}, {Key: KeyEscape}} {
+		got, err := dec.ReadKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != ev {
+			t.Errorf("expected %v, got %v", ev, got)
+		}
+	}
+
+	// The input is not read after of a lone Escape.
+	select {
+	case r <- []byte("x"):
+		t.Error("expected no read after of returning")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDecoderClose(t *testing.T) {
+	r, w := io.Pipe()
+	dec := NewDecoder(r)
+	dec.EscTimeout = 20 * time.Millisecond
+
+	go w.Write([]byte("\x1b"))
+	if got, err := dec.ReadKey(); err != nil || got.Key != KeyEscape {
+		t.Fatalf("expected Escape, got %v, %v", got, err)
+	}
+
+	dec.Close()
+	if _, err := dec.ReadKey(); err == nil {
+		t.Error("expected error after of closing")
+	}
+	w.Close()
+}
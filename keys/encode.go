@@ -0,0 +1,116 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package keys
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Encode returns the bytes sent by a terminal compatible with xterm when the
+// key is pressed, so they are decoded as the same key. It returns nil for
+// KeyUnknown, and for a rune which is not valid.
+//
+// The characters with modifiers which can not be sent as control characters
+// are encoded as "ESC [ codepoint ; modifiers u".
+func Encode(ev KeyEvent) []byte {
+	mod := ev.Mod & (ModShift | ModAlt | ModCtrl | ModMeta)
+
+	// Alt is sent as Escape before the key.
+	if mod&ModAlt != 0 && mod&ModMeta == 0 {
+		if b := encodeKey(ev.Key, ev.Rune, mod&^ModAlt); b != nil && b[0] != esc {
+			return append([]byte{esc}, b...)
+		}
+	}
+	return encodeKey(ev.Key, ev.Rune, mod)
+}
+
+// ss3Keys are the keys sent as "ESC O final" without modifiers, and as
+// "ESC [ 1 ; modifiers final" with them.
+var ss3Keys = map[Key]byte{
+	KeyF1: 'P', KeyF2: 'Q', KeyF3: 'R', KeyF4: 'S',
+}
+
+// csiKeys are the keys sent as "ESC [ final", or as "ESC [ 1 ; modifiers final".
+var csiKeys = map[Key]byte{
+	KeyUp: 'A', KeyDown: 'B', KeyRight: 'C', KeyLeft: 'D',
+	KeyHome: 'H', KeyEnd: 'F',
+}
+
+// tildeCodes are the codes of the keys sent in VT220 format.
+var tildeCodes = map[Key]int{
+	KeyInsert: 2, KeyDelete: 3, KeyPageUp: 5, KeyPageDown: 6,
+	KeyF5: 15, KeyF6: 17, KeyF7: 18, KeyF8: 19, KeyF9: 20, KeyF10: 21,
+	KeyF11: 23, KeyF12: 24,
+}
+
+func encodeKey(key Key, r rune, mod Mod) []byte {
+	// xterm sends F13 to F24 like F1 to F12 with Shift.
+	if key >= KeyF13 && key <= KeyF24 {
+		key -= 12
+		mod |= ModShift
+	}
+	param := strconv.Itoa(int(mod) + 1)
+
+	if final, ok := ss3Keys[key]; ok {
+		if mod == 0 {
+			return []byte{esc, 'O', final}
+		}
+		return []byte("\033[1;" + param + string(final))
+	}
+	if final, ok := csiKeys[key]; ok {
+		if mod == 0 {
+			return []byte{esc, '[', final}
+		}
+		return []byte("\033[1;" + param + string(final))
+	}
+	if code, ok := tildeCodes[key]; ok {
+		if mod == 0 {
+			return []byte("\033[" + strconv.Itoa(code) + "~")
+		}
+		return []byte("\033[" + strconv.Itoa(code) + ";" + param + "~")
+	}
+
+	var code rune
+	switch key {
+	case KeyRune:
+		if !utf8.ValidRune(r) {
+			return nil
+		}
+		code = r
+		switch {
+		case mod == 0:
+			b := make([]byte, utf8.RuneLen(r))
+			utf8.EncodeRune(b, r)
+			return b
+		case mod == ModCtrl && r == ' ':
+			return []byte{0}
+		case mod == ModCtrl && r >= 'a' && r <= 'z':
+			return []byte{byte(r - 'a' + 1)}
+		case mod == ModCtrl && r >= '\\' && r <= '_':
+			return []byte{byte(r - '@')}
+		}
+	case KeyEnter:
+		code = 13
+	case KeyTab:
+		if mod == ModShift {
+			return []byte("\033[Z")
+		}
+		code = 9
+	case KeyBackspace:
+		code = 127
+	case KeyEscape:
+		code = 27
+	default:
+		return nil
+	}
+
+	if mod == 0 {
+		return []byte{byte(code)}
+	}
+	return []byte("\033[" + strconv.Itoa(int(code)) + ";" + param + "u")
+}
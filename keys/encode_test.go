@@ -0,0 +1,70 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package keys
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+var encodeTests = []struct {
+	ev  KeyEvent
+	out string
+}{
+	{KeyEvent{Rune: 'a'}, "a"},
+	{KeyEvent{Rune: 'ñ'}, "ñ"},
+	{KeyEvent{Rune: 'c', Mod: ModCtrl}, "\x03"},
+	{KeyEvent{Rune: ' ', Mod: ModCtrl}, "\x00"},
+	{KeyEvent{Rune: ']', Mod: ModCtrl}, "\x1d"},
+	{KeyEvent{Rune: 'x', Mod: ModAlt}, "\033x"},
+	{KeyEvent{Rune: 'x', Mod: ModAlt | ModCtrl}, "\033\x18"},
+	{KeyEvent{Rune: 'A', Mod: ModCtrl}, "\033[65;5u"},
+
+	{KeyEvent{Key: KeyEnter}, "\r"},
+	{KeyEvent{Key: KeyEnter, Mod: ModAlt}, "\033\r"},
+	{KeyEvent{Key: KeyEnter, Mod: ModShift}, "\033[13;2u"},
+	{KeyEvent{Key: KeyTab}, "\t"},
+	{KeyEvent{Key: KeyTab, Mod: ModShift}, "\033[Z"},
+	{KeyEvent{Key: KeyBackspace}, "\x7f"},
+	{KeyEvent{Key: KeyEscape}, "\033"},
+
+	{KeyEvent{Key: KeyUp}, "\033[A"},
+	{KeyEvent{Key: KeyLeft, Mod: ModCtrl}, "\033[1;5D"},
+	{KeyEvent{Key: KeyUp, Mod: ModAlt}, "\033[1;3A"},
+	{KeyEvent{Key: KeyEnd}, "\033[F"},
+	{KeyEvent{Key: KeyDelete}, "\033[3~"},
+	{KeyEvent{Key: KeyPageDown, Mod: ModShift}, "\033[6;2~"},
+	{KeyEvent{Key: KeyF1}, "\033OP"},
+	{KeyEvent{Key: KeyF4, Mod: ModCtrl}, "\033[1;5S"},
+	{KeyEvent{Key: KeyF12}, "\033[24~"},
+	{KeyEvent{Key: KeyF13}, "\033[1;2P"},
+	{KeyEvent{Key: KeyF20, Mod: ModCtrl}, "\033[19;6~"},
+}
+
+func TestEncode(t *testing.T) {
+	for _, tt := range encodeTests {
+		out := Encode(tt.ev)
+		if string(out) != tt.out {
+			t.Errorf("%s: expected %q, got %q", tt.ev, tt.out, out)
+			continue
+		}
+
+		// The decoder has to get the same key.
+		if ev, n := Decode(out, true); ev != tt.ev || n != len(out) {
+			t.Errorf("%s: decoded as %s, using %d bytes", tt.ev, ev, n)
+		}
+	}
+
+	if out := Encode(KeyEvent{Key: KeyUnknown}); out != nil {
+		t.Errorf("KeyUnknown: expected nil, got %q", out)
+	}
+	for _, r := range []rune{0xD800, -1, utf8.MaxRune + 1} {
+		if out := Encode(KeyEvent{Rune: r}); out != nil {
+			t.Errorf("rune %#x: expected nil, got %q", r, out)
+		}
+	}
+}
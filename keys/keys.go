@@ -0,0 +1,163 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package keys decodes the input of a terminal into key events.
+//
+// It handles the escape sequences sent by the terminals compatible with xterm,
+// VT220, rxvt and the Linux console:
+//
+//   Arrows, Home, End, Insert, Delete, Page Up, Page Down
+//   F1 to F24
+//   Modifiers Shift, Alt, Ctrl and Meta, in xterm format
+//   Alt+key, sent as Escape followed by the key
+//
+// A lone Escape is distinguished from the start of an escape sequence through
+// a timeout, since they are sent in the same way.
+//
+// The control characters are decoded as the key pressed with Ctrl, i.e. the
+// byte 1 is Ctrl+a, but for Tab, Enter, Backspace and Escape.
+//
+// The reports of the mouse are decoded in the encodings X10, SGR and urxvt, and
+// the text pasted in the bracketed paste mode is decoded as a whole.
+package keys
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Key represents a key.
+type Key int
+
+const (
+	KeyRune    Key = iota // A character, got from the field Rune.
+	KeyUnknown            // An escape sequence which is not recognized.
+
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyEscape
+
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyInsert
+	KeyDelete
+	KeyPageUp
+	KeyPageDown
+
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyF13
+	KeyF14
+	KeyF15
+	KeyF16
+	KeyF17
+	KeyF18
+	KeyF19
+	KeyF20
+	KeyF21
+	KeyF22
+	KeyF23
+	KeyF24
+)
+
+var keyNames = []string{
+	KeyRune:      "Rune",
+	KeyUnknown:   "Unknown",
+	KeyEnter:     "Enter",
+	KeyTab:       "Tab",
+	KeyBackspace: "Backspace",
+	KeyEscape:    "Escape",
+	KeyUp:        "Up",
+	KeyDown:      "Down",
+	KeyRight:     "Right",
+	KeyLeft:      "Left",
+	KeyHome:      "Home",
+	KeyEnd:       "End",
+	KeyInsert:    "Insert",
+	KeyDelete:    "Delete",
+	KeyPageUp:    "PageUp",
+	KeyPageDown:  "PageDown",
+}
+
+func (k Key) String() string {
+	if k >= KeyF1 && k <= KeyF24 {
+		return "F" + strconv.Itoa(int(k-KeyF1)+1)
+	}
+	if k >= 0 && int(k) < len(keyNames) {
+		return keyNames[k]
+	}
+	return "Key(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Mod represents the modifier keys pressed together with a key.
+type Mod int
+
+const (
+	ModShift Mod = 1 << iota
+	ModAlt
+	ModCtrl
+	ModMeta
+)
+
+func (m Mod) String() string {
+	var s []string
+
+	if m&ModShift != 0 {
+		s = append(s, "Shift")
+	}
+	if m&ModAlt != 0 {
+		s = append(s, "Alt")
+	}
+	if m&ModCtrl != 0 {
+		s = append(s, "Ctrl")
+	}
+	if m&ModMeta != 0 {
+		s = append(s, "Meta")
+	}
+	return strings.Join(s, "+")
+}
+
+// An Event represents an event read from the input, which is a KeyEvent, a
+// MouseEvent or a PasteEvent.
+type Event interface {
+	String() string
+	event()
+}
+
+// A KeyEvent represents a key pressed.
+type KeyEvent struct {
+	Key  Key
+	Rune rune // Character, if Key is KeyRune.
+	Mod  Mod
+}
+
+func (e KeyEvent) String() string {
+	s := e.Key.String()
+	if e.Key == KeyRune {
+		s = string(e.Rune)
+	}
+	if e.Mod != 0 {
+		return e.Mod.String() + "+" + s
+	}
+	return s
+}
+
+func (KeyEvent) event() {}
@@ -0,0 +1,188 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package keys
+
+import "strconv"
+
+// MouseButton represents a button of the mouse.
+type MouseButton int
+
+const (
+	ButtonNone MouseButton = iota // Motion without buttons, or release in X10 encoding.
+	ButtonLeft
+	ButtonMiddle
+	ButtonRight
+	WheelUp
+	WheelDown
+	WheelLeft
+	WheelRight
+	Button8
+	Button9
+	Button10
+	Button11
+)
+
+var buttonNames = []string{
+	ButtonNone:   "None",
+	ButtonLeft:   "Left",
+	ButtonMiddle: "Middle",
+	ButtonRight:  "Right",
+	WheelUp:      "WheelUp",
+	WheelDown:    "WheelDown",
+	WheelLeft:    "WheelLeft",
+	WheelRight:   "WheelRight",
+	Button8:      "Button8",
+	Button9:      "Button9",
+	Button10:     "Button10",
+	Button11:     "Button11",
+}
+
+func (b MouseButton) String() string {
+	if b >= 0 && int(b) < len(buttonNames) {
+		return buttonNames[b]
+	}
+	return "MouseButton(" + strconv.Itoa(int(b)) + ")"
+}
+
+// MouseAction represents what the mouse has done.
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseMotion
+)
+
+func (a MouseAction) String() string {
+	switch a {
+	case MousePress:
+		return "Press"
+	case MouseRelease:
+		return "Release"
+	case MouseMotion:
+		return "Motion"
+	}
+	return "MouseAction(" + strconv.Itoa(int(a)) + ")"
+}
+
+// A MouseEvent represents a report of the mouse.
+//
+// X is the column and Y the row, starting at 1 like in the escape sequences.
+// In the X10 encoding, the coordinates are limited to 223.
+type MouseEvent struct {
+	X, Y   int
+	Button MouseButton
+	Action MouseAction
+	Mods   Mod // Shift, Alt (Meta in xterm) and Ctrl.
+}
+
+func (e MouseEvent) String() string {
+	s := e.Button.String() + "+" + e.Action.String()
+	if e.Mods != 0 {
+		s = e.Mods.String() + "+" + s
+	}
+	return s + "(" + strconv.Itoa(e.X) + "," + strconv.Itoa(e.Y) + ")"
+}
+
+func (MouseEvent) event() {}
+
+// decodeMouse decodes the reports of the mouse in the encodings X10,
+// "ESC [ M Cb Cx Cy", SGR, "ESC [ < Cb ; Cx ; Cy M", and urxvt,
+// "ESC [ Cb ; Cx ; Cy M". It returns false if p is not a report.
+func decodeMouse(p []byte) (ev MouseEvent, n int, ok bool) {
+	if len(p) < 3 {
+		return
+	}
+
+	// X10
+	if p[2] == 'M' {
+		if len(p) < 6 {
+			return ev, 0, true
+		}
+		ev = mouseEvent(int(p[3])-32, int(p[4])-32, int(p[5])-32, false)
+		return ev, 6, true
+	}
+
+	sgr := p[2] == '<'
+	i := 2
+	if sgr {
+		i++
+	}
+
+	// Three numeric parameters.
+	var params [3]int
+	for j := 0; j < len(params); j++ {
+		start := i
+		for ; i < len(p) && p[i] >= '0' && p[i] <= '9'; i++ {
+		}
+		if i == len(p) {
+			if sgr || i != start {
+				return ev, 0, true // incomplete
+			}
+			return
+		}
+		if i == start {
+			return
+		}
+		params[j] = atoi(p[start:i])
+
+		if j < len(params)-1 {
+			if p[i] != ';' {
+				return
+			}
+			i++
+		}
+	}
+
+	switch {
+	case p[i] == 'M':
+	case p[i] == 'm' && sgr:
+	default:
+		return
+	}
+	if !sgr {
+		params[0] -= 32
+	}
+	ev = mouseEvent(params[0], params[1], params[2], p[i] == 'm')
+	return ev, i + 1, true
+}
+
+// mouseEvent returns the event from the button code and the coordinates.
+// In the SGR encoding, the release of a button is indicated in the final byte.
+func mouseEvent(code, x, y int, release bool) MouseEvent {
+	ev := MouseEvent{X: x, Y: y}
+
+	if code&4 != 0 {
+		ev.Mods |= ModShift
+	}
+	if code&8 != 0 {
+		ev.Mods |= ModAlt
+	}
+	if code&16 != 0 {
+		ev.Mods |= ModCtrl
+	}
+
+	button := code & 3
+	switch {
+	case code&128 != 0:
+		ev.Button = Button8 + MouseButton(button)
+	case code&64 != 0:
+		ev.Button = WheelUp + MouseButton(button)
+	case button == 3:
+		ev.Button = ButtonNone
+		ev.Action = MouseRelease
+	default:
+		ev.Button = ButtonLeft + MouseButton(button)
+	}
+
+	if code&32 != 0 {
+		ev.Action = MouseMotion
+	} else if release {
+		ev.Action = MouseRelease
+	}
+	return ev
+}
@@ -0,0 +1,45 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package keys
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Delimiters of the text pasted in the bracketed paste mode.
+var (
+	pasteStart = []byte("\033[200~")
+	pasteEnd   = []byte("\033[201~")
+)
+
+// A PasteEvent represents a text pasted, when the bracketed paste mode is
+// enabled in the terminal. The text is got as it is, without decoding keys.
+type PasteEvent struct {
+	Text string
+}
+
+func (e PasteEvent) String() string {
+	return "Paste(" + strconv.Quote(e.Text) + ")"
+}
+
+func (PasteEvent) event() {}
+
+// decodePaste decodes a text pasted, which has to start with pasteStart. The
+// text is incomplete until pasteEnd is found, unless flush is set.
+func decodePaste(p []byte, flush bool) (PasteEvent, int) {
+	text := p[len(pasteStart):]
+
+	i := bytes.Index(text, pasteEnd)
+	if i == -1 {
+		if flush {
+			return PasteEvent{string(text)}, len(p)
+		}
+		return PasteEvent{}, 0
+	}
+	return PasteEvent{string(text[:i])}, len(pasteStart) + i + len(pasteEnd)
+}
@@ -0,0 +1,83 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"fmt"
+
+	"github.com/kless/terminal/ansi"
+)
+
+// MouseMode represents a mode of mouse tracking, or an encoding of the reports
+// sent by the terminal. The reports are decoded by package keys.
+type MouseMode int
+
+// Tracking modes.
+const (
+	MouseX10       = MouseMode(ansi.MouseX10)       // Button press.
+	MouseNormal    = MouseMode(ansi.MouseNormal)    // Button press and release.
+	MouseButton    = MouseMode(ansi.MouseButton)    // Like MouseNormal, plus motion with a button pressed.
+	MouseAnyMotion = MouseMode(ansi.MouseAnyMotion) // Like MouseNormal, plus any motion.
+)
+
+// Encodings, to use together with a tracking mode.
+const (
+	MouseSGR   = MouseMode(ansi.MouseSGR)   // Without limit of coordinates, and with the button released.
+	MouseURXVT = MouseMode(ansi.MouseURXVT) // Without limit of coordinates.
+)
+
+var mouseModes = []MouseMode{
+	MouseX10, MouseNormal, MouseButton, MouseAnyMotion, MouseSGR, MouseURXVT,
+}
+
+// EnableMouse enables the reports of the mouse, i.e. EnableMouse(MouseNormal,
+// MouseSGR). They are disabled by DisableMouse, and by Restore.
+func (t *Terminal) EnableMouse(modes ...MouseMode) error {
+	privModes := make([]ansi.Mode, len(modes))
+
+	for i, m := range modes {
+		if !isMouseMode(m) {
+			return fmt.Errorf("terminal: invalid mouse mode: %d", m)
+		}
+		privModes[i] = ansi.Mode(m)
+	}
+
+	if err := t.setPrivateModes(true, privModes...); err != nil {
+		return fmt.Errorf("terminal: could not enable mouse: %s", err)
+	}
+	return nil
+}
+
+// DisableMouse disables the reports of the mouse enabled through EnableMouse.
+func (t *Terminal) DisableMouse() error {
+	var privModes []ansi.Mode
+
+	for i := len(mouseModes) - 1; i >= 0; i-- {
+		if m := ansi.Mode(mouseModes[i]); t.hasPrivateMode(m) {
+			privModes = append(privModes, m)
+		}
+	}
+	if len(privModes) == 0 {
+		return nil
+	}
+
+	if err := t.setPrivateModes(false, privModes...); err != nil {
+		return fmt.Errorf("terminal: could not disable mouse: %s", err)
+	}
+	return nil
+}
+
+func isMouseMode(m MouseMode) bool {
+	for _, v := range mouseModes {
+		if v == m {
+			return true
+		}
+	}
+	return false
+}
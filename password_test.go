@@ -0,0 +1,144 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadPassword(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	// The input is not echoed before of being read.
+	if err = pty.RawMode(); err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("x", 300)
+
+	tests := []struct {
+		in   string
+		mask rune
+		pass string
+		echo string
+		err  error
+	}{
+		{"secret\r", 0, "secret", "", nil},
+		{"ab\x7fc\n", '*', "ac", "**\b \b*", nil},
+		{"añ\x7f\x7fb\r", '*', "b", "**\b \b\b \b*", nil},
+		{"abc\x15d\r", '*', "d", "***\b \b\b \b\b \b*", nil},
+		{"a\x04\x1b[Ab\x1bOPc\x1b[1;5Dd\x1bxe\x1b\r", 0, "abcde", "", nil},
+		{long + "\r", 0, long, "", nil},
+		{"ab\x03", '*', "", "**", ErrInterrupted},
+		{"\x04", 0, "", "", io.EOF},
+	}
+
+	for _, tt := range tests {
+		if _, err = pty.Master.Write([]byte(tt.in)); err != nil {
+			t.Fatal(err)
+		}
+		pass, err := ReadPasswordContext(context.Background(), pty.Fd(), tt.mask)
+		if err != tt.err {
+			t.Errorf("%q: expected error %v, got %v", tt.in, tt.err, err)
+		}
+		if string(pass) != tt.pass {
+			t.Errorf("%q: expected password %q, got %q", tt.in, tt.pass, pass)
+		}
+
+		readOutput(t, pty, tt.echo)
+	}
+
+	// The input after the newline is not read.
+	if _, err = pty.Master.Write([]byte("pass\rnext\r")); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"pass", "next"} {
+		pass, err := ReadPasswordContext(context.Background(), pty.Fd(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pass, []byte(want)) {
+			t.Errorf("expected password %q, got %q", want, pass)
+		}
+	}
+}
+
+func TestReadPasswordHangup(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.RawMode(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := ReadPasswordContext(context.Background(), pty.Fd(), 0)
+		done <- err
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	pty.Master.Close()
+
+	select {
+	case err = <-done:
+		if err != io.EOF {
+			t.Errorf("expected error %v, got %v", io.EOF, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected to return at hanging up")
+	}
+}
+
+func TestReadPasswordContext(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.RawMode(); err != nil {
+		t.Fatal(err)
+	}
+	var oldState, state termios
+	if err = tcgetattr(pty.Fd(), &oldState); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if _, err = pty.Master.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	pass, err := ReadPasswordContext(ctx, pty.Fd(), 0)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+	if pass != nil {
+		t.Errorf("expected no password, got %q", pass)
+	}
+
+	if err = tcgetattr(pty.Fd(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state != oldState {
+		t.Error("expected to restore the terminal")
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInterrupted is returned when the reading of a password is interrupted by
+// Ctrl+C.
+var ErrInterrupted = errors.New("terminal: interrupted")
+
+// Control characters handled at reading a password.
+const (
+	keyInterrupt = 0x03 // Ctrl+C
+	keyEOF       = 0x04 // Ctrl+D
+	keyBackspace = 0x08 // Ctrl+H
+	keyKill      = 0x15 // Ctrl+U
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// Escape sequences skipped at reading a password, like the ones sent by the
+// arrow keys.
+const (
+	seqNone = iota
+	seqEscape
+	seqCSI // ESC [ parameters final
+	seqSS3 // ESC O final
+)
+
+// hangupReads is the number of reads without input, returned before of the
+// time of waiting, to consider that the terminal has been hung up.
+const hangupReads = 3
+
+// ReadPasswordContext reads a password from the terminal until Enter, without
+// echo. If mask is not zero, it is written for each character typed. The
+// characters can be erased with Backspace, and all of them with Ctrl+U; the
+// rest of control characters, and the escape sequences sent by keys like the
+// arrows, are ignored.
+//
+// It returns ErrInterrupted at pressing Ctrl+C, which does not send SIGINT
+// meanwhile, and io.EOF at pressing Ctrl+D without characters, or when the
+// terminal is hung up. The context is
+// checked every tenth of second, returning its error when it is done. The
+// newline is not echoed, and the input after it is not read.
+//
+// The buffers used to read are zeroed, so the password is only kept in the one
+// returned, which should be zeroed by the caller after of using it.
+func ReadPasswordContext(ctx context.Context, fd int, mask rune) ([]byte, error) {
+	var oldState termios
+
+	if err := tcgetattr(fd, &oldState); err != nil {
+		return nil, fmt.Errorf("terminal: could not read password: %s", err)
+	}
+
+	// Read returns after of a tenth of second without input, to check the
+	// context.
+	newState := oldState
+	newState.Lflag &^= (ECHO | ECHOE | ECHOK | ECHONL | ICANON | ISIG)
+	newState.Cc[VMIN] = 0
+	newState.Cc[VTIME] = 1
+
+	if err := tcsetattr(fd, _TCSANOW, &newState); err != nil {
+		return nil, fmt.Errorf("terminal: could not turn off echo: %s", err)
+	}
+	defer tcsetattr(fd, _TCSANOW, &oldState)
+
+	var echo, erase []byte
+	if mask != 0 {
+		echo = []byte(string(mask))
+		erase = []byte("\b \b")
+	}
+
+	var pass []byte
+	var buf [1]byte // to not read the input after the newline
+	defer zero(buf[:])
+
+	seq := seqNone
+	hangup := 0
+
+	fail := func(err error) ([]byte, error) {
+		zero(pass)
+		return nil, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+
+		start := time.Now()
+		n, err := syscall.Read(fd, buf[:])
+		if err != nil {
+			switch err {
+			case syscall.EAGAIN: // non-blocking
+				sleepContext(ctx)
+			case syscall.EINTR:
+			case syscall.EIO: // hung up
+				return fail(io.EOF)
+			default:
+				return fail(fmt.Errorf("terminal: could not read password: %s", err))
+			}
+		}
+		if n <= 0 {
+			// A hung up terminal returns without waiting for the input.
+			if err == nil && time.Since(start) < 50*time.Millisecond {
+				if hangup++; hangup == hangupReads {
+					return fail(io.EOF)
+				}
+			} else {
+				hangup = 0
+			}
+			seq = seqNone // a single Escape
+			continue
+		}
+		hangup = 0
+
+		c := buf[0]
+		switch seq {
+		case seqEscape:
+			switch {
+			case c == '[':
+				seq = seqCSI
+				continue
+			case c == 'O':
+				seq = seqSS3
+				continue
+			case c < 0x20 || c == keyDelete: // after a single Escape
+				seq = seqNone
+			default: // Alt+key
+				seq = seqNone
+				continue
+			}
+		case seqCSI:
+			if c >= 0x40 && c <= 0x7e {
+				seq = seqNone
+			}
+			continue
+		case seqSS3:
+			seq = seqNone
+			continue
+		}
+
+		switch {
+		case c == keyEscape:
+			seq = seqEscape
+		case c == '\n' || c == '\r':
+			return pass, nil
+		case c == keyInterrupt:
+			return fail(ErrInterrupted)
+		case c == keyEOF:
+			if len(pass) == 0 {
+				return fail(io.EOF)
+			}
+		case c == keyBackspace || c == keyDelete || c == oldState.Cc[VERASE]:
+			if len(pass) != 0 {
+				pass = erasePassword(pass, 1)
+				if mask != 0 {
+					writeAll(fd, erase)
+				}
+			}
+		case c == keyKill || c == oldState.Cc[VKILL]:
+			if n := utf8.RuneCount(pass); n != 0 {
+				pass = erasePassword(pass, n)
+				if mask != 0 {
+					writeAll(fd, bytes.Repeat(erase, n))
+				}
+			}
+		case c < 0x20: // control character
+		default:
+			pass = appendPassword(pass, c)
+			// The mask is written at the first byte of a character, so the
+			// characters split across reads are counted once.
+			if mask != 0 && utf8.RuneStart(c) {
+				writeAll(fd, echo)
+			}
+		}
+	}
+}
+
+// appendPassword appends the byte to the password, zeroing the old array when
+// it has to be grown.
+func appendPassword(pass []byte, c byte) []byte {
+	if len(pass) == cap(pass) {
+		p := make([]byte, len(pass), 2*cap(pass)+16)
+		copy(p, pass)
+		zero(pass)
+		pass = p
+	}
+	return append(pass, c)
+}
+
+// erasePassword erases the last n characters of the password, zeroing them.
+func erasePassword(pass []byte, n int) []byte {
+	i := len(pass)
+	for ; n > 0 && i > 0; n-- {
+		// The byte of start of a character, or the first one of an incomplete
+		// sequence.
+		for i--; i > 0 && !utf8.RuneStart(pass[i]); i-- {
+		}
+	}
+	zero(pass[i:])
+	return pass[:i]
+}
+
+// zero sets to zero the bytes.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// sleepContext waits a tenth of second for a non-blocking read, or until the
+// context is done.
+func sleepContext(ctx context.Context) {
+	t := time.NewTimer(100 * time.Millisecond)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// ReadPassword reads the input until '\n' without echo.
+// Returns the number of bytes read.
+//
+// Deprecated: the password is truncated to the length of pass, returning
+// io.ErrShortBuffer; use ReadPasswordContext.
+func ReadPassword(fd int, pass []byte) (n int, err error) {
+	p, err := ReadPasswordContext(context.Background(), fd, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer zero(p)
+
+	if n = copy(pass, p); n < len(p) {
+		err = io.ErrShortBuffer
+	}
+	return n, err
+}
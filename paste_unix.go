@@ -0,0 +1,38 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"fmt"
+
+	"github.com/kless/terminal/ansi"
+)
+
+// EnableBracketedPaste enables the bracketed paste mode, where the terminal
+// sends the text pasted between "ESC [ 200 ~" and "ESC [ 201 ~", so it can be
+// distinguished from the keys typed. The text is decoded by package keys.
+//
+// It is disabled by DisableBracketedPaste, and by Restore.
+func (t *Terminal) EnableBracketedPaste() error {
+	if err := t.setPrivateModes(true, ansi.BracketedPaste); err != nil {
+		return fmt.Errorf("terminal: could not enable bracketed paste: %s", err)
+	}
+	return nil
+}
+
+// DisableBracketedPaste disables the bracketed paste mode.
+func (t *Terminal) DisableBracketedPaste() error {
+	if !t.hasPrivateMode(ansi.BracketedPaste) {
+		return nil
+	}
+	if err := t.setPrivateModes(false, ansi.BracketedPaste); err != nil {
+		return fmt.Errorf("terminal: could not disable bracketed paste: %s", err)
+	}
+	return nil
+}
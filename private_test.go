@@ -0,0 +1,216 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/kless/terminal/ansi"
+)
+
+func TestMouse(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.RawMode(); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.EnableMouse(MouseButton, MouseSGR); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.EnableMouse(MouseMode(2004)); err == nil {
+		t.Error("expected error for an invalid mouse mode")
+	}
+	if err = pty.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing to disable after of Restore.
+	if err = pty.DisableMouse(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\033[?1002h\033[?1006h\033[?1006l\033[?1002l"
+	buf := make([]byte, len(want))
+	for n := 0; n < len(buf); {
+		i, err := pty.Master.Read(buf[n:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += i
+	}
+	if string(buf) != want {
+		t.Errorf("expected output %q, got %q", want, buf)
+	}
+}
+
+func TestBracketedPaste(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.RawMode(); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.EnableBracketedPaste(); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.EnableMouse(MouseNormal); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.DisableBracketedPaste(); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.Restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\033[?2004h\033[?1000h\033[?2004l\033[?1000l"
+	buf := make([]byte, len(want))
+	for n := 0; n < len(buf); {
+		i, err := pty.Master.Read(buf[n:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += i
+	}
+	if string(buf) != want {
+		t.Errorf("expected output %q, got %q", want, buf)
+	}
+}
+
+func TestAltScreen(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.EnterAltScreen(); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.HideCursor(); err != nil {
+		t.Fatal(err)
+	}
+	if err = pty.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing to exit after of Restore.
+	if err = pty.ExitAltScreen(); err != nil {
+		t.Fatal(err)
+	}
+
+	readOutput(t, pty, "\033[?1049h\033[?25l\033[?25h\033[?1049l")
+}
+
+func TestRestoreWriteError(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	// The modes can not be reset through a descriptor opened to read.
+	f, err := os.OpenFile(pty.Name(), os.O_RDONLY|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	term, err := New(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = term.RawMode(); err != nil {
+		t.Fatal(err)
+	}
+	term.privModes = []ansi.Mode{ansi.AltScreen}
+	term.cursorHidden = true
+
+	if err = term.Restore(); err == nil {
+		t.Error("expected error at writing the modes")
+	}
+
+	var state termios
+	if err = tcgetattr(pty.Fd(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state != term.oldState {
+		t.Error("expected to restore the settings")
+	}
+}
+
+func TestFullScreen(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	// The mode already set is not undone.
+	if err = pty.EnableBracketedPaste(); err != nil {
+		t.Fatal(err)
+	}
+	oldState := pty.lastState
+
+	scr, err := NewFullScreen(pty.Terminal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pty.lastState.Lflag&ICANON != 0 {
+		t.Error("expected raw mode")
+	}
+	if err = scr.EnableMouse(MouseNormal, MouseSGR); err != nil {
+		t.Fatal(err)
+	}
+	if err = scr.EnableBracketedPaste(); err != nil {
+		t.Fatal(err)
+	}
+	if err = scr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = scr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if pty.lastState != oldState || pty.mod&rawMode != 0 {
+		t.Error("expected to restore the state")
+	}
+	if state, err := pty.CurrentState(); err != nil || state.wrap.Lflag != oldState.Lflag {
+		t.Errorf("expected to restore the terminal state: %v", err)
+	}
+
+	readOutput(t, pty, "\033[?2004h"+
+		"\033[?1049h\033[?25l\033[?1000h\033[?1006h"+
+		"\033[?1006l\033[?1000l\033[?25h\033[?1049l")
+	if !pty.hasPrivateMode(2004) {
+		t.Error("expected bracketed paste mode not undone")
+	}
+}
+
+// readOutput checks the output written to the pseudo-terminal.
+func readOutput(t *testing.T, pty *PTY, want string) {
+	buf := make([]byte, len(want))
+	for n := 0; n < len(buf); {
+		i, err := pty.Master.Read(buf[n:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += i
+	}
+	if string(buf) != want {
+		t.Errorf("expected output %q, got %q", want, buf)
+	}
+}
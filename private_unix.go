@@ -0,0 +1,88 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"bytes"
+	"syscall"
+
+	"github.com/kless/terminal/ansi"
+)
+
+// The DEC private modes are set through escape sequences written to the
+// terminal, so its file descriptor has to be opened for writing too, like it
+// is the standard input in a terminal.
+//
+// The modes set are tracked to be reset by Restore.
+
+// setPrivateModes sets or resets the DEC private modes.
+func (t *Terminal) setPrivateModes(on bool, modes ...ansi.Mode) error {
+	var b bytes.Buffer
+
+	for _, m := range modes {
+		if on {
+			b.WriteString(ansi.SetMode(m))
+		} else {
+			b.WriteString(ansi.ResetMode(m))
+		}
+	}
+	if err := writeAll(t.fd, b.Bytes()); err != nil {
+		return err
+	}
+
+	for _, m := range modes {
+		i := 0
+		for ; i < len(t.privModes); i++ {
+			if t.privModes[i] == m {
+				break
+			}
+		}
+
+		if on && i == len(t.privModes) {
+			t.privModes = append(t.privModes, m)
+		} else if !on && i != len(t.privModes) {
+			t.privModes = append(t.privModes[:i], t.privModes[i+1:]...)
+		}
+	}
+	return nil
+}
+
+// resetPrivateModes resets all modes set, in reverse order.
+func (t *Terminal) resetPrivateModes() error {
+	modes := make([]ansi.Mode, len(t.privModes))
+	for i, m := range t.privModes {
+		modes[len(modes)-1-i] = m
+	}
+	return t.setPrivateModes(false, modes...)
+}
+
+// hasPrivateMode reports whether the mode has been set.
+func (t *Terminal) hasPrivateMode(mode ansi.Mode) bool {
+	for _, m := range t.privModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAll writes the whole buffer to the file descriptor.
+func writeAll(fd int, b []byte) error {
+	for len(b) != 0 {
+		n, err := syscall.Write(fd, b)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: man posix_openpt ; <sys/ttycom.h> */
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Pseudo-terminal control
+const (
+	_TIOCPTYGRANT = 0x20007454 // grantpt(3)
+	_TIOCPTYUNLK  = 0x20007452 // unlockpt(3)
+	_TIOCPTYGNAME = 0x40807453 // ptsname(3)
+)
+
+// openpty opens the master side through the multiplexor "/dev/ptmx", and then
+// the slave side.
+func openpty() (master, slave *os.File, err error) {
+	fd, err := syscall.Open("/dev/ptmx",
+		syscall.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("terminal: could not open pty master: %s", err)
+	}
+
+	if err = grantpt(fd); err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not grant pty: %s", err)
+	}
+	if err = unlockpt(fd); err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not unlock pty: %s", err)
+	}
+	name, err := ptsname(fd)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not get pty name: %s", err)
+	}
+	master = os.NewFile(uintptr(fd), "/dev/ptmx")
+
+	slave, err = os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("terminal: could not open pty slave: %s", err)
+	}
+	return master, slave, nil
+}
+
+//sys	int grantpt(int fd)
+
+func grantpt(fd int) error {
+	return ioctl(fd, _TIOCPTYGRANT, 0)
+}
+
+//sys	int unlockpt(int fd)
+
+func unlockpt(fd int) error {
+	return ioctl(fd, _TIOCPTYUNLK, 0)
+}
+
+//sys	char *ptsname(int fd)
+
+func ptsname(fd int) (string, error) {
+	var name [128]byte
+	if err := ioctl(fd, _TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0]))); err != nil {
+		return "", err
+	}
+	return cstring(name[:]), nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: man posix_openpt ; man pts */
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// _FIODGNAME gets the name of a device; it is used by ptsname(3).
+const _FIODGNAME = 0x80106678
+
+type fiodgnameArg struct {
+	Len int32
+	_   [4]byte
+	Buf *byte
+}
+
+// openpty opens the master side through posix_openpt, and then the slave side
+// in "/dev/pts".
+//
+// Under pts(4), the slave is already owned by the user and unlocked, so there
+// is no need of grantpt nor unlockpt.
+func openpty() (master, slave *os.File, err error) {
+	fd, err := posixOpenpt(syscall.O_RDWR | syscall.O_NOCTTY | syscall.O_CLOEXEC)
+	if err != nil {
+		return nil, nil, fmt.Errorf("terminal: could not open pty master: %s", err)
+	}
+	// The master is non-blocking like in the rest of systems, but it is set
+	// apart since posix_openpt fails with any other flag.
+	if err = syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not open pty master: %s", err)
+	}
+
+	name, err := ptsname(fd)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not get pty name: %s", err)
+	}
+	master = os.NewFile(uintptr(fd), "/dev/ptmx")
+
+	slave, err = os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("terminal: could not open pty slave: %s", err)
+	}
+	return master, slave, nil
+}
+
+//sys	int posix_openpt(int oflag)
+
+func posixOpenpt(oflag int) (fd int, err error) {
+	r0, _, e1 := syscall.Syscall(syscall.SYS_POSIX_OPENPT, uintptr(oflag), 0, 0)
+	fd = int(r0)
+	if e1 != 0 {
+		err = e1
+	}
+	return
+}
+
+//sys	char *ptsname(int fd)
+
+func ptsname(fd int) (string, error) {
+	var name [64]byte
+	arg := fiodgnameArg{Len: int32(len(name)), Buf: &name[0]}
+
+	if err := ioctl(fd, _FIODGNAME, uintptr(unsafe.Pointer(&arg))); err != nil {
+		return "", err
+	}
+	return "/dev/" + cstring(name[:]), nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: man pts ; man ptmx */
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// openpty opens the master side through the multiplexor "/dev/ptmx", and then
+// the slave side in "/dev/pts".
+//
+// Under devpts, the slave is already owned by the user, so there is no need
+// of grantpt.
+func openpty() (master, slave *os.File, err error) {
+	fd, err := syscall.Open("/dev/ptmx",
+		syscall.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("terminal: could not open pty master: %s", err)
+	}
+
+	if err = unlockpt(fd); err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not unlock pty: %s", err)
+	}
+	name, err := ptsname(fd)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not get pty name: %s", err)
+	}
+	master = os.NewFile(uintptr(fd), "/dev/ptmx")
+
+	slave, err = os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("terminal: could not open pty slave: %s", err)
+	}
+	return master, slave, nil
+}
+
+//sys	int unlockpt(int fd)
+
+func unlockpt(fd int) error {
+	var lock int32
+	return ioctl(fd, _TIOCSPTLCK, uintptr(unsafe.Pointer(&lock)))
+}
+
+//sys	char *ptsname(int fd)
+
+func ptsname(fd int) (string, error) {
+	var n uint32
+	if err := ioctl(fd, _TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		return "", err
+	}
+	return "/dev/pts/" + strconv.Itoa(int(n)), nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: man ptm ; man posix_openpt */
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Pseudo-terminal control
+const (
+	_TIOCGRANTPT = 0x20007447 // grantpt(3)
+	_TIOCPTSNAME = 0x48087448 // ptsname(3)
+)
+
+type ptmget struct {
+	Cfd int32
+	Sfd int32
+	Cn  [1024]byte
+	Sn  [1024]byte
+}
+
+// openpty opens the master side through the multiplexor "/dev/ptmx", and then
+// the slave side.
+//
+// The slave is unlocked at opening, so there is no need of unlockpt.
+func openpty() (master, slave *os.File, err error) {
+	fd, err := syscall.Open("/dev/ptmx",
+		syscall.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("terminal: could not open pty master: %s", err)
+	}
+
+	if err = grantpt(fd); err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not grant pty: %s", err)
+	}
+	name, err := ptsname(fd)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, nil, fmt.Errorf("terminal: could not get pty name: %s", err)
+	}
+	master = os.NewFile(uintptr(fd), "/dev/ptmx")
+
+	slave, err = os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("terminal: could not open pty slave: %s", err)
+	}
+	return master, slave, nil
+}
+
+//sys	int grantpt(int fd)
+
+func grantpt(fd int) error {
+	return ioctl(fd, _TIOCGRANTPT, 0)
+}
+
+//sys	char *ptsname(int fd)
+
+func ptsname(fd int) (string, error) {
+	var ptm ptmget
+	if err := ioctl(fd, _TIOCPTSNAME, uintptr(unsafe.Pointer(&ptm))); err != nil {
+		return "", err
+	}
+	return cstring(ptm.Sn[:]), nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: man ptm */
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// _PTMGET gets both sides of a new pseudo-terminal, already granted and
+// unlocked.
+const _PTMGET = 0x40287401
+
+type ptmget struct {
+	Cfd int32
+	Sfd int32
+	Cn  [16]byte
+	Sn  [16]byte
+}
+
+// openpty gets both sides through the pseudo-terminal multiplexor "/dev/ptm".
+func openpty() (master, slave *os.File, err error) {
+	fd, err := syscall.Open("/dev/ptm", syscall.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("terminal: could not open pty multiplexor: %s", err)
+	}
+	defer syscall.Close(fd)
+
+	var ptm ptmget
+	if err = ioctl(fd, _PTMGET, uintptr(unsafe.Pointer(&ptm))); err != nil {
+		return nil, nil, fmt.Errorf("terminal: could not get pty: %s", err)
+	}
+
+	syscall.CloseOnExec(int(ptm.Cfd))
+	syscall.CloseOnExec(int(ptm.Sfd))
+	return os.NewFile(uintptr(ptm.Cfd), cstring(ptm.Cn[:])),
+		os.NewFile(uintptr(ptm.Sfd), cstring(ptm.Sn[:])), nil
+}
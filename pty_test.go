@@ -0,0 +1,110 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"testing"
+)
+
+func TestPTY(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if !IsTerminal(pty.Fd()) {
+		t.Error("expected slave to be a terminal")
+	}
+	if pty.Name() == "" {
+		t.Error("expected to get the slave name")
+	}
+
+	if err = pty.RawMode(); err != nil {
+		t.Fatal("expected set raw mode:", err)
+	}
+	if _, err = pty.Master.Write([]byte("ab")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 2)
+	for n := 0; n < len(buf); {
+		i, err := pty.Slave.Read(buf[n:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += i
+	}
+	if string(buf) != "ab" {
+		t.Errorf("expected to read %q from slave, got %q", "ab", buf)
+	}
+
+	if err = pty.Restore(); err != nil {
+		t.Error("expected to restore:", err)
+	}
+}
+
+func TestStartCommand(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "test -t 0 && echo tty")
+
+	master, err := StartCommand(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer master.Close()
+
+	var out bytes.Buffer
+	io.Copy(&out, master) // until the child exits
+
+	if err = cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "tty\r\n" {
+		t.Errorf("expected command attached to a terminal, got output %q", got)
+	}
+}
+
+func TestSetSize(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.SetSize(24, 80, 640, 384); err != nil {
+		t.Fatal(err)
+	}
+
+	row, col, err := pty.GetSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row != 24 || col != 80 {
+		t.Errorf("expected size 24x80, got %dx%d", row, col)
+	}
+
+	x, y, err := pty.GetSizePixels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x != 640 || y != 384 {
+		t.Errorf("expected size in pixels 640x384, got %dx%d", x, y)
+	}
+
+	w, h, err := pty.CellSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != 8 || h != 16 {
+		t.Errorf("expected cell size 8x16, got %dx%d", w, h)
+	}
+}
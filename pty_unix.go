@@ -0,0 +1,63 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import "os"
+
+// A PTY represents a pseudo-terminal pair.
+//
+// The master side is used by the program which controls the terminal, while
+// the slave side is used as terminal by the programs attached to it. The
+// embedded Terminal is set on the slave side.
+type PTY struct {
+	Master *os.File
+	Slave  *os.File
+
+	*Terminal
+}
+
+// OpenPTY opens a new pseudo-terminal pair.
+func OpenPTY() (*PTY, error) {
+	master, slave, err := openpty()
+	if err != nil {
+		return nil, err
+	}
+
+	term, err := New(int(slave.Fd()))
+	if err != nil {
+		master.Close()
+		slave.Close()
+		return nil, err
+	}
+	return &PTY{master, slave, term}, nil
+}
+
+// Name returns the name of the slave side.
+func (p *PTY) Name() string {
+	return p.Slave.Name()
+}
+
+// Close closes both sides of the pseudo-terminal.
+func (p *PTY) Close() error {
+	err := p.Slave.Close()
+	if e := p.Master.Close(); e != nil {
+		err = e
+	}
+	return err
+}
+
+// cstring returns the string stored into a buffer ended in a null character.
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,174 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeTerminal replies to the requests read from the master side of a
+// pseudo-terminal, in order, like a terminal emulator.
+func fakeTerminal(pty *PTY, replies map[string]string) {
+	buf := make([]byte, 64)
+	var in []byte
+
+	for {
+		n, err := pty.Master.Read(buf)
+		if err != nil {
+			return
+		}
+		in = append(in, buf[:n]...)
+
+		for {
+			first, i := "", len(in)
+			for req := range replies {
+				if j := bytes.Index(in, []byte(req)); j != -1 && j < i {
+					first, i = req, j
+				}
+			}
+			if first == "" {
+				break
+			}
+
+			in = append(in[:i], in[i+len(first):]...)
+			pty.Master.Write([]byte(replies[first]))
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	go fakeTerminal(pty, map[string]string{
+		// The keys typed meanwhile are sent around the replies.
+		"\033[c":   "ab\033[?62;4;22c\033[A",
+		"\033[>c":  "\033[>41;354;0c",
+		"\033[>0q": "\033P>|xterm(354)\033\\",
+		"\033[6n":  "\033\033[12;40Rc",
+	})
+
+	attr, err := pty.DeviceAttributes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(attr, []int{62, 4, 22}) {
+		t.Errorf("DA1: got %v", attr)
+	}
+
+	if attr, err = pty.SecondaryDeviceAttributes(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(attr, []int{41, 354, 0}) {
+		t.Errorf("DA2: got %v", attr)
+	}
+
+	version, err := pty.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "xterm(354)" {
+		t.Errorf("XTVERSION: got %q", version)
+	}
+
+	row, col, err := pty.CursorPosition()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row != 12 || col != 40 {
+		t.Errorf("CPR: got %d;%d", row, col)
+	}
+
+	// Typeahead
+	want := "ab\033[A" + "ab\033[A" + "\033c"
+	if pty.Buffered() != len(want) {
+		t.Fatalf("expected %d bytes buffered, got %d", len(want), pty.Buffered())
+	}
+	buf := make([]byte, 64)
+	n, err := pty.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:n]) != want {
+		t.Errorf("expected typeahead %q, got %q", want, buf[:n])
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	go fakeTerminal(pty, map[string]string{"\033[c": "\033[?1;2c"})
+
+	QueryTimeout = 200 * time.Millisecond
+	defer func() { QueryTimeout = 1 * time.Second }()
+
+	start := time.Now()
+	if _, _, err = pty.CursorPosition(); err != ErrNoReply {
+		t.Errorf("expected ErrNoReply, got %v", err)
+	}
+	if d := time.Since(start); d < QueryTimeout || d > 2*QueryTimeout {
+		t.Errorf("unexpected time waiting for reply: %s", d)
+	}
+
+	// Without XTVERSION, the reply to DA1 is got.
+	start = time.Now()
+	if _, err = pty.Version(); err != ErrNoReply {
+		t.Errorf("expected ErrNoReply, got %v", err)
+	}
+	if d := time.Since(start); d >= QueryTimeout {
+		t.Errorf("unexpected time waiting for reply: %s", d)
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.RawMode(); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 8)
+
+	start := time.Now()
+	if n, err := pty.ReadTimeout(buf, 50*time.Millisecond); n != 0 || err != nil {
+		t.Errorf("expected no input, got %d, %v", n, err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond || d > time.Second {
+		t.Errorf("unexpected time waiting for input: %s", d)
+	}
+
+	if _, err = pty.Master.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := pty.ReadTimeout(buf, 50*time.Millisecond); n != 1 || err != nil {
+		t.Errorf("expected input, got %d, %v", n, err)
+	}
+
+	// The terminal is set as it was.
+	var state termios
+	if err = tcgetattr(pty.Fd(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state != pty.lastState {
+		t.Error("expected to restore the settings")
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+/* Reference: http://invisible-island.net/xterm/ctlseqs/ctlseqs.html */
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// QueryTimeout is the time to wait for the reply to a query.
+var QueryTimeout = 1 * time.Second
+
+// ErrNoReply is returned when the terminal does not reply to a query.
+var ErrNoReply = errors.New("terminal: no reply to query")
+
+// Query writes a request to the terminal, and reads its reply.
+//
+// Each escape sequence read is passed to reply, which reports whether it is
+// part of the reply, and whether the reply has been completed. The rest of the
+// input, like the keys typed meanwhile, is kept to be got through Read.
+//
+// The input is read in raw mode, without echo, and the terminal mode is
+// restored at returning. It must not be used while another goroutine reads
+// from the terminal.
+func (t *Terminal) Query(request string, timeout time.Duration, reply func(seq []byte) (ok, done bool)) error {
+	var oldState termios
+
+	if err := tcgetattr(t.fd, &oldState); err != nil {
+		return fmt.Errorf("terminal: could not query: %s", err)
+	}
+
+	// Read returns after of a tenth of second without input.
+	newState := oldState
+	newState.Lflag &^= (ECHO | ECHONL | ICANON)
+	newState.Cc[VMIN] = 0
+	newState.Cc[VTIME] = 1
+
+	if err := tcsetattr(t.fd, _TCSANOW, &newState); err != nil {
+		return fmt.Errorf("terminal: could not query: %s", err)
+	}
+	defer tcsetattr(t.fd, _TCSANOW, &oldState)
+
+	if err := writeAll(t.fd, []byte(request)); err != nil {
+		return fmt.Errorf("terminal: could not query: %s", err)
+	}
+
+	var in []byte // input not processed
+	buf := make([]byte, 256)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		// Pass the complete sequences.
+		for len(in) != 0 {
+			n := sequenceLen(in)
+			if n == 0 {
+				break
+			}
+
+			ok, done := reply(in[:n])
+			if !ok {
+				t.typeahead = append(t.typeahead, in[:n]...)
+			}
+			in = in[n:]
+
+			if done {
+				t.typeahead = append(t.typeahead, in...)
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			t.typeahead = append(t.typeahead, in...)
+			return ErrNoReply
+		}
+
+		n, err := syscall.Read(t.fd, buf)
+		if err != nil {
+			switch err {
+			case syscall.EAGAIN: // non-blocking
+				time.Sleep(10 * time.Millisecond)
+			case syscall.EINTR:
+			default:
+				t.typeahead = append(t.typeahead, in...)
+				return fmt.Errorf("terminal: could not query: %s", err)
+			}
+		}
+		if n > 0 {
+			in = append(in, buf[:n]...)
+		}
+	}
+}
+
+// Read reads from the terminal, getting first the input kept by Query.
+func (t *Terminal) Read(p []byte) (int, error) {
+	if len(t.typeahead) != 0 {
+		n := copy(p, t.typeahead)
+		t.typeahead = t.typeahead[n:]
+		return n, nil
+	}
+
+	n, err := syscall.Read(t.fd, p)
+	if n < 0 {
+		n = 0
+	}
+	return n, err
+}
+
+// ReadTimeout is like Read, but it returns zero bytes and no error when there
+// is no input after of the timeout, which is rounded up to tenths of second.
+// It is used to wait for the rest of an escape sequence without leaving a read
+// pending, through keys.Decoder.
+func (t *Terminal) ReadTimeout(p []byte, timeout time.Duration) (int, error) {
+	if len(t.typeahead) != 0 {
+		return t.Read(p)
+	}
+
+	tenths := (timeout + 100*time.Millisecond - 1) / (100 * time.Millisecond)
+	if tenths < 1 {
+		tenths = 1
+	} else if tenths > 255 {
+		tenths = 255
+	}
+
+	state := t.lastState
+	state.Cc[VMIN] = 0
+	state.Cc[VTIME] = uint8(tenths)
+
+	if err := tcsetattr(t.fd, _TCSANOW, &state); err != nil {
+		return 0, fmt.Errorf("terminal: could not read: %s", err)
+	}
+	defer tcsetattr(t.fd, _TCSANOW, &t.lastState)
+
+	for {
+		n, err := syscall.Read(t.fd, p)
+		if err == syscall.EINTR {
+			continue
+		}
+		if n < 0 {
+			n = 0
+		}
+		return n, err
+	}
+}
+
+// Buffered returns the number of bytes kept by Query, which can be got through
+// Read without reading from the terminal.
+func (t *Terminal) Buffered() int {
+	return len(t.typeahead)
+}
+
+// == Queries
+//
+
+// DeviceAttributes returns the primary device attributes (DA1): the class of
+// terminal, and the features supported, i.e. 4 for sixel graphics.
+func (t *Terminal) DeviceAttributes() ([]int, error) {
+	var params []int
+
+	err := t.Query("\033[c", QueryTimeout, func(seq []byte) (bool, bool) {
+		p, ok := csiParams(seq, '?', 'c')
+		if ok {
+			params = p
+		}
+		return ok, ok
+	})
+	return params, err
+}
+
+// SecondaryDeviceAttributes returns the secondary device attributes (DA2): the
+// type of terminal, its firmware version and the ROM cartridge number.
+func (t *Terminal) SecondaryDeviceAttributes() ([]int, error) {
+	var params []int
+
+	err := t.Query("\033[>c", QueryTimeout, func(seq []byte) (bool, bool) {
+		p, ok := csiParams(seq, '>', 'c')
+		if ok {
+			params = p
+		}
+		return ok, ok
+	})
+	return params, err
+}
+
+// Version returns the name and version of the terminal emulator (XTVERSION),
+// i.e. "xterm(354)".
+//
+// The primary device attributes are requested after, since all terminals
+// reply to them, so ErrNoReply is returned without waiting for the timeout when
+// it is not supported.
+func (t *Terminal) Version() (string, error) {
+	var version string
+	var found bool
+
+	err := t.Query("\033[>0q\033[c", QueryTimeout, func(seq []byte) (bool, bool) {
+		if bytes.HasPrefix(seq, []byte("\033P>|")) {
+			version = string(bytes.TrimSuffix(seq[4:], []byte("\033\\")))
+			found = true
+			return true, false
+		}
+		if _, ok := csiParams(seq, '?', 'c'); ok {
+			return true, true
+		}
+		return false, false
+	})
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", ErrNoReply
+	}
+	return version, nil
+}
+
+// CursorPosition returns the position of the cursor, starting at 1, through
+// the device status report (DSR).
+func (t *Terminal) CursorPosition() (row, column int, err error) {
+	err = t.Query("\033[6n", QueryTimeout, func(seq []byte) (bool, bool) {
+		p, ok := csiParams(seq, 0, 'R')
+		if ok && len(p) == 2 {
+			row, column = p[0], p[1]
+			return true, true
+		}
+		return false, false
+	})
+	return
+}
+
+// == Utility
+//
+
+// csiParams returns the numeric parameters of a control sequence with the
+// given private marker, if any, and final byte.
+func csiParams(seq []byte, private, final byte) (params []int, ok bool) {
+	if len(seq) < 3 || seq[0] != 27 || seq[1] != '[' || seq[len(seq)-1] != final {
+		return nil, false
+	}
+	seq = seq[2 : len(seq)-1]
+
+	if private != 0 {
+		if len(seq) == 0 || seq[0] != private {
+			return nil, false
+		}
+		seq = seq[1:]
+	}
+	if len(seq) == 0 {
+		return nil, true
+	}
+
+	for _, f := range bytes.Split(seq, []byte(";")) {
+		n, err := strconv.Atoi(string(f))
+		if err != nil {
+			return nil, false
+		}
+		params = append(params, n)
+	}
+	return params, true
+}
+
+// sequenceLen returns the length of the escape sequence or character at the
+// start of b, or 0 if it is incomplete.
+func sequenceLen(b []byte) int {
+	if b[0] != 27 {
+		return 1
+	}
+	if len(b) == 1 {
+		return 0
+	}
+
+	switch b[1] {
+	case 27: // a lone Escape
+		return 1
+	case '[': // Control Sequence Introducer
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7E {
+				return i + 1
+			}
+			if b[i] < 0x20 || b[i] > 0x3F {
+				return i // malformed
+			}
+		}
+		return 0
+
+	case 'P', ']', '_', '^': // strings, ended by ST or by BEL in OSC
+		for i := 2; i < len(b); i++ {
+			if b[i] == 7 && b[1] == ']' {
+				return i + 1
+			}
+			if b[i] == 27 && i+1 < len(b) {
+				if b[i+1] == '\\' {
+					return i + 2
+				}
+				return i // malformed
+			}
+		}
+		return 0
+	}
+	return 2
+}
@@ -6,11 +6,53 @@
 
 package quest
 
-// ANSI codes to set graphic mode
-const (
-	setOff  = "\033[0m" // All attributes off
-	setBold = "\033[1m" // Bold on
+import (
+	"os"
+	"sync"
+
+	"github.com/kless/terminal"
+	"github.com/kless/terminal/ansi"
+	"github.com/kless/terminal/editline"
+	"github.com/kless/terminal/style"
+)
+
+// eraseLine erases the line, and moves the cursor to its start.
+var eraseLine = ansi.EraseLine(ansi.EraseAll) + "\r"
+
+// The values by default are shown in bold, with a color according to the
+// background of the terminal, which are got at the first question.
+var (
+	defaultStyle = style.New().Bold()
+	depth        = style.NoColor
+	defaultOnce  sync.Once
 )
 
-// The values by default are set to bold.
-const lenAnsi = len(setBold) + len(setOff)
+// lenAnsi returns the length of the ANSI codes used in the values by default.
+func lenAnsi() int { return len(defaultStyle.Render(depth, "")) }
+
+// setDefaultStyle sets the depth of colors of the output, and the color of the
+// values by default: yellow is readable on a dark background, and blue on a
+// light one. It is not colored when the background is unknown.
+//
+// The background is requested to the terminal only if the output has colors.
+// The depth is got without requesting the 24-bit colors, which are not used.
+func setDefaultStyle(term *terminal.Terminal) {
+	f, ok := editline.Output.(*os.File)
+	if !ok {
+		return
+	}
+	depth = style.DetectEnv(int(f.Fd()))
+	if depth < style.Color16 {
+		return
+	}
+
+	colors, err := term.DefaultColors()
+	if err != nil {
+		return
+	}
+	if colors.Dark {
+		defaultStyle = defaultStyle.Fg(style.Yellow)
+	} else {
+		defaultStyle = defaultStyle.Fg(style.Blue)
+	}
+}
@@ -12,10 +12,12 @@ package quest
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/kless/terminal"
+	"github.com/kless/terminal/ansi"
 	"github.com/kless/terminal/editline"
 	"github.com/kless/validate"
 )
@@ -69,6 +71,7 @@ func New(prefix, errPrefix, trueString, falseString string) *Question {
 	if err != nil {
 		panic(err)
 	}
+	defaultOnce.Do(func() { setDefaultStyle(term) })
 
 	extraBool := make(map[string]bool)
 	val := validate.New(validate.Bool, validate.NONE)
@@ -135,7 +138,7 @@ func (q *Question) ExitAtCtrlC(n int) {
 		select {
 		case <-editline.ChanCtrlC:
 			q.Restore()
-			editline.Output.Write(editline.DelLine_CR)
+			io.WriteString(editline.Output, eraseLine)
 			os.Exit(n)
 		}
 	}()
@@ -189,24 +192,24 @@ func (q *Question) read(line *editline.Line, valida *validate.Validate) (interfa
 		val, err := valida.Get(input)
 
 		if err == validate.ErrRequired {
-			os.Stderr.Write(editline.DelLine_CR)
+			io.WriteString(os.Stderr, eraseLine)
 			fmt.Fprintf(os.Stderr, "%s it %s", q.errPrefix, err)
-			editline.Output.Write(editline.CursorUp)
+			io.WriteString(editline.Output, ansi.CursorUp(1))
 			hadError = true
 			continue
 		}
 
 		// Error of type.
 		if err != nil {
-			os.Stderr.Write(editline.DelLine_CR)
+			io.WriteString(os.Stderr, eraseLine)
 			fmt.Fprintf(os.Stderr, "%s %q %s", q.errPrefix, input, err)
-			editline.Output.Write(editline.CursorUp)
+			io.WriteString(editline.Output, ansi.CursorUp(1))
 			hadError = true
 			continue
 		}
 
 		if hadError {
-			os.Stderr.Write(editline.DelLine_CR)
+			io.WriteString(os.Stderr, eraseLine)
 		}
 		return val, nil
 	}
@@ -361,15 +364,15 @@ func (q *Question) ReadEmail() (string, error) {
 
 // defaultToPrint returns the default value.
 func defaultToPrint(val interface{}) string {
-	return fmt.Sprintf(" [%s%v%s]", setBold, val, setOff)
+	return fmt.Sprintf(" [%s]", defaultStyle.Render(depth, fmt.Sprint(val)))
 }
 
 // defaultBoolToPrint returns the default value for a boolean.
 func (q *Question) defaultBoolToPrint(val bool) string {
 	if val {
-		return fmt.Sprintf(" [%s%s%s/%s]", setBold, q.trueString, setOff, q.falseString)
+		return fmt.Sprintf(" [%s/%s]", defaultStyle.Render(depth, q.trueString), q.falseString)
 	}
-	return fmt.Sprintf(" [%s/%s%s%s]", q.trueString, setBold, q.falseString, setOff)
+	return fmt.Sprintf(" [%s/%s]", q.trueString, defaultStyle.Render(depth, q.falseString))
 }
 
 // newLine gets a line type ready to show questions.
@@ -389,7 +392,7 @@ func (q *Question) newLine() *editline.Line {
 
 		// The default value uses ANSI characters.
 		if q.defValue != nil {
-			extraChars = lenAnsi
+			extraChars = lenAnsi()
 		}
 	} else {
 		prompt = q_MULTIPLE_PREFIX
@@ -4,113 +4,148 @@
 // License, v. 2.0. If a copy of the MPL was not distributed with this
 // file, You can obtain one at http://mozilla.org/MPL/2.0/.
 
+// +build !plan9,!windows
+
 package quest
 
 import (
-	"flag"
 	"fmt"
-	"io"
 	"os"
+	"os/exec"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/kless/terminal/editline"
+	"github.com/kless/terminal/expect"
 )
 
-var (
-	fInteractive = flag.Bool("int", false, "mode interactive")
-	fTime        = flag.Uint("t", 2, "time in seconds to wait to write answer")
-
-	// Mode interactive:
-	// It is needed a fifo for os.Stdin (os.Stderr is used by 'go test') since
-	// it is connected to the TTY. So, you write go to the terminal.
-	pr *io.PipeReader
-	pw *io.PipeWriter
-)
+// The test binary is run in a pseudo-terminal to ask the questions named in
+// this variable.
+const helperEnv = "QUEST_TEST_HELPER"
 
-func init() {
-	flag.Parse()
-
-	if *fInteractive {
-		editline.Input = os.Stderr
-	} else {
-		pr, pw = io.Pipe()
-		editline.Input = pr
+// TestHelperProcess is not a real test; it asks the questions in the process
+// started by startHelper.
+func TestHelperProcess(t *testing.T) {
+	questions := os.Getenv(helperEnv)
+	if questions == "" {
+		return
+	}
+	editline.Input = os.Stdin
+	editline.InputFd = syscall.Stdin
+
+	switch questions {
+	case "default":
+		askDefault()
+	case "extraBool":
+		askExtraBool()
 	}
-	editline.InputFd = syscall.Stderr
+	os.Exit(0)
+}
+
+// An answer is the input sent to a question, which has to print the answer
+// wanted. The inputs but the last one are invalid.
+type answer struct {
+	prompt string
+	input  []string
+	want   string
 }
 
 func TestQuest(t *testing.T) {
-	var ans interface{}
-	var err error
+	testAnswers(t, "default", []answer{
+		{"What is your name?", []string{"", "R. C."}, "R. C."},
+		{"What color is your hair?", []string{""}, "brown"},
+		{"What temperature is there?", []string{"foo", "-11"}, "-11"},
+		{"How old are you?", []string{"-11", ""}, "16"},
+		{"How tall are you?", []string{"foo", "1.23"}, "1.23"},
+		{"Do you watch television?", []string{""}, "true"},
+		{"Do you read books?", []string{"false"}, "false"},
+		{"What is your favourite color?", []string{"foo", ""}, "blue"},
+		{"Another favourite color?", []string{"foo", "black"}, "black"},
+		{"Choose number", []string{"2", "1"}, "1"},
+		{"Email", []string{""}, "ja@contac.me"},
+		{q_MULTIPLE_PREFIX, []string{"photo", "cryp", ""}, "[photo cryp]"},
+	})
+}
 
-	fmt.Print("\n== Questions\n\n")
+func TestQuestExtraBoolean(t *testing.T) {
+	testAnswers(t, "extraBool", []answer{
+		{"Are you french?", []string{"ja", "oui"}, "true"},
+	})
+}
 
-	q := NewDefault()
-	defer q.Restore()
+// testAnswers sends the inputs to the questions asked in the helper process,
+// waiting for each prompt, and checks the answers printed.
+func testAnswers(t *testing.T, questions string, answers []answer) {
+	e := startHelper(t, questions)
+	defer e.Close()
 
-	if *fInteractive {
-		q.ExitAtCtrlC(1)
-	} else {
-		Int := "-11"
-		Float := "1.23"
-		String := "foo"
-		False := "false"
-		True := "true"
-
-		auto := map[int][]string{
-			1:  []string{Float, True, "", "R. C."},
-			2:  []string{""},
-			3:  []string{String, Int},
-			4:  []string{True, Int, ""},
-			5:  []string{String, Float},
-			6:  []string{String, ""},
-			7:  []string{False},
-			8:  []string{String, ""},
-			9:  []string{String, "5", "2"},
-			10: []string{"2", "1"},
-			11: []string{String, ""},
-			12: []string{"photo", "cryp", ""},
-		}
+	for _, a := range answers {
+		for _, in := range a.input {
+			if _, err := e.ExpectString(a.prompt, 5*time.Second); err != nil {
+				t.Fatalf("%q: %s; output %q", a.prompt, err, e.Output())
+			}
+			e.SendLine(in)
 
-		go func() {
-			for i := 1; i <= 12; i++ {
-				for _, v := range auto[i] {
-					time.Sleep(time.Duration(*fTime) * time.Second)
-					fmt.Fprintf(pw, "%s%s", v, editline.CRLF) // remember that terminal is in raw mode
-				}
+			// Each question is read from a new line, which discards the
+			// input sent before of it.
+			if _, err := e.ExpectString("\r\n", 5*time.Second); err != nil {
+				t.Fatalf("%q: %s; output %q", a.prompt, err, e.Output())
 			}
-		}()
+		}
+		if _, err := e.ExpectString("answer: "+a.want+"\r\n", 5*time.Second); err != nil {
+			t.Errorf("%q: expected answer %q: %s; output %q", a.prompt, a.want, err, e.Output())
+		}
+	}
+	if err := e.Wait(); err != nil {
+		t.Errorf("helper process: %s; output %q", err, e.Transcript())
 	}
+}
+
+// startHelper runs the test binary in a pseudo-terminal, to ask the questions.
+// The colors are disabled, so the prompts are not styled.
+func startHelper(t *testing.T, questions string) *expect.Expect {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), helperEnv+"="+questions, "TERM=xterm", "NO_COLOR=1")
+
+	e, err := expect.Start(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func askDefault() {
+	q := NewDefault()
+	defer q.Restore()
 
 	q.NewPrompt("1. What is your name?").Mod(REQUIRED)
-	ans, err = q.ReadString()
-	print("\""+ans.(string)+"\"", err)
+	ans, err := q.ReadString()
+	print(ans, err)
 
 	q.NewPrompt("2. What color is your hair?").Default("brown")
 	ans, err = q.ReadString()
 	print(ans, err)
 
 	q.NewPrompt("3. What temperature is there?").Default(-2)
-	ans, err = q.ReadInt()
-	print(ans, err)
+	n, err := q.ReadInt()
+	print(n, err)
 
 	q.NewPrompt("4. How old are you?").Default(uint(16))
-	ans, err = q.ReadUint()
-	print(ans, err)
+	u, err := q.ReadUint()
+	print(u, err)
 
 	q.NewPrompt("5. How tall are you?").Mod(REQUIRED)
-	ans, err = q.ReadFloat()
-	print(ans, err)
+	f, err := q.ReadFloat()
+	print(f, err)
 
 	q.NewPrompt("6. Do you watch television?").Default(true)
-	ans, err = q.ReadBool()
-	print(ans, err)
+	b, err := q.ReadBool()
+	print(b, err)
 
 	q.NewPrompt("7. Do you read books?").Default(false)
-	ans, err = q.ReadBool()
-	print(ans, err)
+	b, err = q.ReadBool()
+	print(b, err)
 
 	color := []string{"red", "blue", "black"}
 	q.NewPrompt("8. What is your favourite color?").Default("blue")
@@ -122,35 +157,23 @@ func TestQuest(t *testing.T) {
 	print(ans, err)
 
 	q.NewPrompt("10. Choose number").Default(uint(3))
-	ans, err = q.ChoiceUint([]uint{1, 3, 5})
-	print(ans, err)
+	u, err = q.ChoiceUint([]uint{1, 3, 5})
+	print(u, err)
 
 	q.NewPrompt("11. Email").Default("ja@contac.me")
 	ans, err = q.ReadEmail()
 	print(ans, err)
 
 	q.NewPrompt("12. Hobby")
-	ans, err = q.ReadMultipleString()
-	print(ans, err)
+	hobbies, err := q.ReadMultipleString()
+	print(hobbies, err)
 }
 
-func TestQuestExtraBoolean(t *testing.T) {
-	fmt.Println("\n===\n  *NOTE:* It has been added the boolean strings 'oui', 'non'")
-
+func askExtraBool() {
+	// It is added the boolean strings 'oui', 'non'.
 	q := New(" > ", "  ERR:", "oui", "non")
 	defer q.Restore()
 
-	if *fInteractive {
-		q.ExitAtCtrlC(1)
-	} else {
-		go func() {
-			for _, v := range []string{"ja", "oui"} {
-				time.Sleep(time.Duration(*fTime) * time.Second)
-				fmt.Fprintf(pw, "%s%s", v, editline.CRLF)
-			}
-		}()
-	}
-
 	q.NewPrompt("13. Are you french?").Mod(REQUIRED)
 	ans, err := q.ReadBool()
 	print(ans, err)
@@ -0,0 +1,86 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNotifyResize(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.SetSize(24, 80, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c1 := pty.NotifyResize(ctx)
+	c2 := pty.NotifyResize(ctx)
+
+	// The process is not in the foreground of the pseudo-terminal, so the
+	// signal is sent to itself.
+	resize := func(rows, cols int) {
+		if err := pty.SetSize(rows, cols, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		syscall.Kill(os.Getpid(), syscall.SIGWINCH)
+		time.Sleep(50 * time.Millisecond)
+	}
+	expect := func(c <-chan Size, want Size) {
+		select {
+		case size := <-c:
+			if size != want {
+				t.Errorf("expected size %v, got %v", want, size)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expected size %v, got nothing", want)
+		}
+	}
+	expectNothing := func(c <-chan Size) {
+		select {
+		case size := <-c:
+			t.Errorf("expected nothing, got size %v", size)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	resize(24, 80) // same size
+	expectNothing(c1)
+
+	resize(30, 100)
+	expect(c1, Size{30, 100})
+	expect(c2, Size{30, 100})
+
+	// Burst of changes.
+	resize(31, 101)
+	resize(32, 102)
+	expect(c1, Size{32, 102})
+	expectNothing(c1)
+	expect(c2, Size{32, 102})
+
+	cancel()
+	for _, c := range []<-chan Size{c1, c2} {
+		select {
+		case _, ok := <-c:
+			if ok {
+				t.Error("expected channel closed")
+			}
+		case <-time.After(time.Second):
+			t.Error("expected channel closed")
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Size represents the size of a terminal, in characters.
+type Size struct {
+	Rows, Columns int
+}
+
+// NotifyResize returns a channel which receives the size of the terminal when
+// it changes, got through the signal SIGWINCH. Each call returns a new channel,
+// so there can be several subscribers.
+//
+// The changes are coalesced while the size is not received, so it is got the
+// last one after of a burst, like when the window is resized with the mouse.
+// The channel is closed when the context is done.
+func (t *Terminal) NotifyResize(ctx context.Context) <-chan Size {
+	out := make(chan Size)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+
+	var last Size
+	last.Rows, last.Columns, _ = t.GetSize()
+
+	go func() {
+		defer close(out)
+		defer signal.Stop(sig)
+
+		var size Size
+		pending := false
+
+		for {
+			var send chan<- Size
+			if pending {
+				send = out
+			}
+
+			select {
+			case <-sig:
+				rows, cols, err := t.GetSize()
+				if err != nil {
+					continue
+				}
+				size = Size{rows, cols}
+				pending = size != last
+			case send <- size:
+				last, pending = size, false
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
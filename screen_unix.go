@@ -0,0 +1,191 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"fmt"
+
+	"github.com/kless/terminal/ansi"
+)
+
+// EnterAltScreen switches to the alternate screen, saving the cursor and
+// clearing the screen (DEC mode 1049), so the contents of the normal one are
+// shown again at exiting.
+//
+// It is exited by ExitAltScreen, and by Restore.
+func (t *Terminal) EnterAltScreen() error {
+	if err := t.setPrivateModes(true, ansi.AltScreen); err != nil {
+		return fmt.Errorf("terminal: could not enter alternate screen: %s", err)
+	}
+	return nil
+}
+
+// ExitAltScreen switches to the normal screen, restoring the cursor.
+func (t *Terminal) ExitAltScreen() error {
+	if !t.hasPrivateMode(ansi.AltScreen) {
+		return nil
+	}
+	if err := t.setPrivateModes(false, ansi.AltScreen); err != nil {
+		return fmt.Errorf("terminal: could not exit alternate screen: %s", err)
+	}
+	return nil
+}
+
+// HideCursor hides the cursor. It is shown by ShowCursor, and by Restore.
+func (t *Terminal) HideCursor() error {
+	if err := writeAll(t.fd, []byte(ansi.ResetMode(ansi.ShowCursor))); err != nil {
+		return fmt.Errorf("terminal: could not hide cursor: %s", err)
+	}
+	t.cursorHidden = true
+	return nil
+}
+
+// ShowCursor shows the cursor.
+func (t *Terminal) ShowCursor() error {
+	if err := writeAll(t.fd, []byte(ansi.SetMode(ansi.ShowCursor))); err != nil {
+		return fmt.Errorf("terminal: could not show cursor: %s", err)
+	}
+	t.cursorHidden = false
+	return nil
+}
+
+// == Full screen
+//
+
+// A FullScreen represents a session of a program which takes over the screen,
+// like an editor. It records every mode turned on, so Close undoes all of them
+// in reverse order:
+//
+//   scr, err := terminal.NewFullScreen(term)
+//   if err != nil {
+//   	return err
+//   }
+//   defer scr.Close()
+//
+//   if err = scr.EnableMouse(terminal.MouseNormal, terminal.MouseSGR); err != nil {
+//   	return err
+//   }
+//
+// The modes which were already on when they are turned on by the session are
+// not undone.
+type FullScreen struct {
+	term *Terminal
+	undo []func() error // functions to undo the modes, in the order turned on
+}
+
+// NewFullScreen starts a full-screen session in the terminal: it sets the raw
+// mode, switches to the alternate screen, and hides the cursor. If any of them
+// fails, the ones already done are undone.
+func NewFullScreen(t *Terminal) (*FullScreen, error) {
+	s := &FullScreen{term: t}
+
+	if t.mod&rawMode == 0 {
+		state, mod := t.lastState, t.mod
+
+		if err := t.RawMode(); err != nil {
+			return nil, err
+		}
+		s.push(func() error {
+			if err := tcsetattr(t.fd, _TCSANOW, &state); err != nil {
+				return fmt.Errorf("terminal: could not restore: %s", err)
+			}
+			t.lastState, t.mod = state, mod
+			return nil
+		})
+	}
+
+	if !t.hasPrivateMode(ansi.AltScreen) {
+		if err := t.EnterAltScreen(); err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.push(t.ExitAltScreen)
+	}
+
+	if !t.cursorHidden {
+		if err := t.HideCursor(); err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.push(t.ShowCursor)
+	}
+	return s, nil
+}
+
+// Terminal returns the terminal of the session.
+func (s *FullScreen) Terminal() *Terminal { return s.term }
+
+// EnableMouse enables the reports of the mouse, like Terminal.EnableMouse,
+// to be disabled at closing.
+func (s *FullScreen) EnableMouse(modes ...MouseMode) error {
+	var set []ansi.Mode
+	for _, m := range modes {
+		if !s.term.hasPrivateMode(ansi.Mode(m)) {
+			set = append(set, ansi.Mode(m))
+		}
+	}
+
+	if err := s.term.EnableMouse(modes...); err != nil {
+		return err
+	}
+	if len(set) != 0 {
+		s.push(func() error { return s.resetModes(set) })
+	}
+	return nil
+}
+
+// EnableBracketedPaste enables the bracketed paste mode, like
+// Terminal.EnableBracketedPaste, to be disabled at closing.
+func (s *FullScreen) EnableBracketedPaste() error {
+	if s.term.hasPrivateMode(ansi.BracketedPaste) {
+		return nil
+	}
+	if err := s.term.EnableBracketedPaste(); err != nil {
+		return err
+	}
+	s.push(s.term.DisableBracketedPaste)
+	return nil
+}
+
+// resetModes resets the private modes in reverse order, if they are still set.
+func (s *FullScreen) resetModes(modes []ansi.Mode) error {
+	var reset []ansi.Mode
+	for i := len(modes) - 1; i >= 0; i-- {
+		if s.term.hasPrivateMode(modes[i]) {
+			reset = append(reset, modes[i])
+		}
+	}
+	if len(reset) == 0 {
+		return nil
+	}
+
+	if err := s.term.setPrivateModes(false, reset...); err != nil {
+		return fmt.Errorf("terminal: could not reset modes: %s", err)
+	}
+	return nil
+}
+
+func (s *FullScreen) push(undo func() error) {
+	s.undo = append(s.undo, undo)
+}
+
+// Close undoes the modes turned on by the session, in reverse order. All of
+// them are tried, and it is returned the first error. It can be called more
+// than once.
+func (s *FullScreen) Close() error {
+	var err error
+
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		if e := s.undo[i](); e != nil && err == nil {
+			err = e
+		}
+	}
+	s.undo = nil
+	return err
+}
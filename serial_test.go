@@ -0,0 +1,64 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"testing"
+	"time"
+)
+
+// A pseudo-terminal is used instead of a serial device.
+func TestSerial(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	s, err := OpenSerial(pty.Name(), SerialConfig{
+		Baud:        19200,
+		DataBits:    7,
+		Parity:      ParityEven,
+		StopBits:    TwoStopBits,
+		FlowControl: FlowHardware,
+		ReadTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	state := s.lastState
+	if state.Cflag&CSIZE != CS7 ||
+		state.Cflag&(PARENB|PARODD) != PARENB ||
+		state.Cflag&CSTOPB == 0 ||
+		state.Cflag&CRTSCTS == 0 {
+
+		t.Error("expected to set the control modes")
+	}
+	if _, baud := getSpeed(&state); baud != 19200 {
+		t.Errorf("expected baud rate 19200, got %d", baud)
+	}
+
+	if _, err = pty.Master.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	n, err := s.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:n]) != "ping" {
+		t.Errorf("expected to read %q, got %q", "ping", buf[:n])
+	}
+
+	if _, err = s.Read(buf); err != ErrTimeout {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
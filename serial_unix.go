@@ -0,0 +1,220 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrTimeout is returned when the time to wait for input expires.
+var ErrTimeout = errors.New("terminal: timeout")
+
+// Parity represents the parity checking of a serial line.
+type Parity int
+
+const (
+	ParityNone Parity = iota
+	ParityOdd
+	ParityEven
+)
+
+// StopBits represents the number of stop bits of a serial line.
+type StopBits int
+
+const (
+	OneStopBit StopBits = iota
+	TwoStopBits
+)
+
+// FlowControl represents the flow control of a serial line.
+type FlowControl int
+
+const (
+	FlowNone     FlowControl = iota
+	FlowHardware             // RTS/CTS
+	FlowSoftware             // XON/XOFF
+)
+
+// SerialConfig represents the configuration of a serial line.
+//
+// The baud rate by default is 9600, and the data bits are 8. Arbitrary baud
+// rates are supported in Linux and BSD systems.
+//
+// If ReadTimeout is zero, then Read waits until there is some input. Else,
+// it is rounded to tenths of second, up to 25.5 seconds.
+type SerialConfig struct {
+	Baud        int
+	DataBits    int
+	Parity      Parity
+	StopBits    StopBits
+	FlowControl FlowControl
+	ReadTimeout time.Duration
+}
+
+// A Serial represents a serial line.
+type Serial struct {
+	*Terminal
+	file    *os.File
+	timeout bool
+}
+
+// OpenSerial opens the serial device at path, and configures it in raw mode
+// according to cfg.
+//
+// The device is not set as controlling terminal of the process, and it is
+// opened without waiting for the carrier detect.
+func OpenSerial(path string, cfg SerialConfig) (*Serial, error) {
+	fd, err := syscall.Open(path,
+		syscall.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	// The reading is controlled by the terminal attributes.
+	if err = syscall.SetNonblock(fd, false); err != nil {
+		syscall.Close(fd)
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+
+	term, err := New(fd)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	s := &Serial{Terminal: term, file: os.NewFile(uintptr(fd), path)}
+	if err = s.SetConfig(cfg); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetConfig changes the configuration of the serial line.
+func (s *Serial) SetConfig(cfg SerialConfig) error {
+	state := s.lastState
+
+	// Raw mode, without any processing of input and output.
+	state.Iflag &^= (BRKINT | IGNBRK | ICRNL | INLCR | IGNCR | ISTRIP | IXON |
+		IXOFF | IXANY | PARMRK | INPCK)
+	state.Oflag &^= OPOST
+	state.Lflag &^= (ECHO | ECHONL | ICANON | IEXTEN | ISIG)
+
+	// Enable the receiver, and ignore the modem control lines.
+	state.Cflag |= (CREAD | CLOCAL)
+
+	state.Cflag &^= CSIZE
+	switch cfg.DataBits {
+	case 5:
+		state.Cflag |= CS5
+	case 6:
+		state.Cflag |= CS6
+	case 7:
+		state.Cflag |= CS7
+	case 8, 0:
+		state.Cflag |= CS8
+	default:
+		return fmt.Errorf("terminal: invalid number of data bits: %d", cfg.DataBits)
+	}
+
+	state.Cflag &^= (PARENB | PARODD)
+	switch cfg.Parity {
+	case ParityNone:
+	case ParityOdd:
+		state.Cflag |= (PARENB | PARODD)
+		state.Iflag |= INPCK
+	case ParityEven:
+		state.Cflag |= PARENB
+		state.Iflag |= INPCK
+	default:
+		return fmt.Errorf("terminal: invalid parity: %d", cfg.Parity)
+	}
+
+	switch cfg.StopBits {
+	case OneStopBit:
+		state.Cflag &^= CSTOPB
+	case TwoStopBits:
+		state.Cflag |= CSTOPB
+	default:
+		return fmt.Errorf("terminal: invalid stop bits: %d", cfg.StopBits)
+	}
+
+	state.Cflag &^= CRTSCTS
+	switch cfg.FlowControl {
+	case FlowNone:
+	case FlowHardware:
+		state.Cflag |= CRTSCTS
+	case FlowSoftware:
+		state.Iflag |= (IXON | IXOFF)
+	default:
+		return fmt.Errorf("terminal: invalid flow control: %d", cfg.FlowControl)
+	}
+
+	// Control chars - set return condition: min number of bytes and timer.
+	if cfg.ReadTimeout > 0 {
+		tenths := (cfg.ReadTimeout + 50*time.Millisecond) / (100 * time.Millisecond)
+		if tenths < 1 {
+			tenths = 1
+		} else if tenths > 255 {
+			tenths = 255
+		}
+		state.Cc[VMIN] = 0
+		state.Cc[VTIME] = uint8(tenths)
+	} else {
+		state.Cc[VMIN] = 1
+		state.Cc[VTIME] = 0
+	}
+
+	baud := cfg.Baud
+	if baud == 0 {
+		baud = 9600
+	}
+	isStandard := setSpeed(&state, baud)
+
+	if err := tcsetattr(s.fd, _TCSANOW, &state); err != nil {
+		return fmt.Errorf("terminal: could not configure serial line: %s", err)
+	}
+	if !isStandard {
+		if err := setCustomSpeed(s.fd, baud); err != nil {
+			return fmt.Errorf("terminal: could not set baud rate %d: %s", baud, err)
+		}
+		if err := tcgetattr(s.fd, &state); err != nil {
+			return err
+		}
+	}
+
+	s.lastState = state
+	s.mod |= otherMode
+	s.timeout = cfg.ReadTimeout > 0
+	return nil
+}
+
+// Read reads up to len(b) bytes from the serial line.
+// Returns ErrTimeout if the read timeout expires without input.
+func (s *Serial) Read(b []byte) (n int, err error) {
+	n, err = s.file.Read(b)
+	if err == io.EOF && s.timeout {
+		err = ErrTimeout
+	}
+	return
+}
+
+// Write writes len(b) bytes to the serial line.
+func (s *Serial) Write(b []byte) (n int, err error) {
+	return s.file.Write(b)
+}
+
+// Close closes the serial line.
+func (s *Serial) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,120 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build darwin freebsd netbsd openbsd
+
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Indexes of the control characters which are only in BSD systems.
+const (
+	vdsusp  = 11 // delayed suspend
+	vstatus = 18 // status request
+)
+
+// gfmtChars are the control characters in the format of "stty -g" in BSD,
+// sorted by name.
+var gfmtChars = []sttyChar{
+	{"discard", VDISCARD},
+	{"dsusp", vdsusp},
+	{"eof", VEOF},
+	{"eol", VEOL},
+	{"eol2", VEOL2},
+	{"erase", VERASE},
+	{"intr", VINTR},
+	{"kill", VKILL},
+	{"lnext", VLNEXT},
+	{"min", VMIN},
+	{"quit", VQUIT},
+	{"reprint", VREPRINT},
+	{"start", VSTART},
+	{"status", vstatus},
+	{"stop", VSTOP},
+	{"susp", VSUSP},
+	{"time", VTIME},
+	{"werase", VWERASE},
+}
+
+// sttyEncode returns the termios in the format of "stty -g" in BSD, that is
+// "gfmt1" followed by the pairs name=value of the control, input, local and
+// output modes and the control characters, in hexadecimal, and the speeds,
+// separated by colons.
+func sttyEncode(st *termios) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "gfmt1:cflag=%x:iflag=%x:lflag=%x:oflag=%x:",
+		st.Cflag, st.Iflag, st.Lflag, st.Oflag)
+
+	for _, c := range gfmtChars {
+		fmt.Fprintf(&b, "%s=%x:", c.name, st.Cc[c.index])
+	}
+	fmt.Fprintf(&b, "ispeed=%d:ospeed=%d", st.Ispeed, st.Ospeed)
+	return b.Bytes()
+}
+
+// sttyDecode sets the termios from the format of "stty -g" in BSD. Like in
+// stty, the names unknown are skipped, like "erase2" of FreeBSD.
+func sttyDecode(st *termios, text string) error {
+	fields := strings.Split(strings.TrimSpace(text), ":")
+	if fields[0] != "gfmt1" {
+		return errors.New("not in format gfmt1")
+	}
+
+	for _, f := range fields[1:] {
+		i := strings.IndexByte(f, '=')
+		if i == -1 {
+			return fmt.Errorf("no value in %q", f)
+		}
+		name, value := f[:i], f[i+1:]
+
+		switch name {
+		case "cflag", "iflag", "lflag", "oflag":
+			v, err := strconv.ParseUint(value, 16, 64)
+			if err != nil || uint64(tcflag(v)) != v {
+				return fmt.Errorf("invalid value in %q", f)
+			}
+			switch name {
+			case "cflag":
+				st.Cflag = tcflag(v)
+			case "iflag":
+				st.Iflag = tcflag(v)
+			case "lflag":
+				st.Lflag = tcflag(v)
+			case "oflag":
+				st.Oflag = tcflag(v)
+			}
+		case "ispeed", "ospeed":
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || v < 0 || int64(speed(v)) != v {
+				return fmt.Errorf("invalid value in %q", f)
+			}
+			if name == "ispeed" {
+				st.Ispeed = speed(v)
+			} else {
+				st.Ospeed = speed(v)
+			}
+		default:
+			for _, c := range gfmtChars {
+				if c.name == name {
+					v, err := strconv.ParseUint(value, 16, 8)
+					if err != nil {
+						return fmt.Errorf("invalid value in %q", f)
+					}
+					st.Cc[c.index] = byte(v)
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build darwin freebsd netbsd openbsd
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSttyFormat(t *testing.T) {
+	const text = "gfmt1:cflag=4b00:iflag=6b02:lflag=5cb:oflag=3:discard=f:dsusp=19:" +
+		"eof=4:eol=ff:eol2=ff:erase=7f:intr=3:kill=15:lnext=16:min=1:quit=1c:" +
+		"reprint=12:start=11:status=14:stop=13:susp=1a:time=0:werase=17:" +
+		"ispeed=9600:ospeed=9600"
+
+	var st termios
+	if err := sttyDecode(&st, text+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	if st.Cflag != 0x4b00 || st.Iflag != 0x6b02 || st.Lflag != 0x5cb || st.Oflag != 3 ||
+		st.Cc[VINTR] != 3 || st.Cc[vstatus] != 0x14 || st.Ispeed != 9600 || st.Ospeed != 9600 {
+		t.Errorf("wrong decoding: %+v", st)
+	}
+	if got := string(sttyEncode(&st)); got != text {
+		t.Errorf("expected to encode the same text\n got: %q\nwant: %q", got, text)
+	}
+
+	if err := sttyDecode(&st, strings.Replace(text, "intr=3:", "erase2=8:", 1)); err != nil {
+		t.Errorf("expected to skip an unknown name: %s", err)
+	}
+
+	for _, s := range []string{
+		"500:5:bf:8a3b",
+		strings.Replace(text, "intr=3", "intr=100", 1),
+		strings.Replace(text, "ispeed=9600", "ispeed=-1", 1),
+		strings.Replace(text, ":min=1", ":min", 1),
+	} {
+		if err := sttyDecode(&st, s); err == nil {
+			t.Errorf("expected error at decoding %q", s)
+		}
+	}
+}
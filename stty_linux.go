@@ -0,0 +1,68 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sttyEncode returns the termios in the format of "stty -g" in GNU, that is
+// the input, output, control and local modes, and the control characters, in
+// hexadecimal and separated by colons.
+func sttyEncode(st *termios) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "%x:%x:%x:%x", st.Iflag, st.Oflag, st.Cflag, st.Lflag)
+
+	for i := 0; i < sttyNCCS; i++ {
+		var c byte
+		if i < len(st.Cc) {
+			c = st.Cc[i]
+		}
+		fmt.Fprintf(&b, ":%x", c)
+	}
+	return b.Bytes()
+}
+
+// sttyDecode sets the termios from the format of "stty -g" in GNU.
+func sttyDecode(st *termios, text string) error {
+	fields := strings.Split(text, ":")
+	if len(fields) != 4+sttyNCCS && len(fields) != 4+len(st.Cc) {
+		return errors.New("wrong number of fields")
+	}
+
+	values := make([]uint64, len(fields))
+	for i, f := range fields {
+		bitSize := 8
+		if i < 4 {
+			bitSize = 32
+		}
+
+		v, err := strconv.ParseUint(f, 16, bitSize)
+		if err != nil {
+			return err
+		}
+		values[i] = v
+	}
+
+	st.Iflag = tcflag(values[0])
+	st.Oflag = tcflag(values[1])
+	st.Cflag = tcflag(values[2])
+	st.Lflag = tcflag(values[3])
+
+	for i, v := range values[4:] {
+		if i == len(st.Cc) {
+			break
+		}
+		st.Cc[i] = byte(v)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSttyFormat(t *testing.T) {
+	const text = "500:5:bf:8a3b:3:1c:7f:15:4:0:1:0:11:13:1a:0:12:f:17:16:0:0:0:0:0:0:0:0:0:0:0:0:0:0:0:0"
+
+	var st termios
+	if err := sttyDecode(&st, text); err != nil {
+		t.Fatal(err)
+	}
+	if st.Iflag != 0x500 || st.Oflag != 5 || st.Cflag != 0xbf || st.Lflag != 0x8a3b ||
+		st.Cc[VINTR] != 3 || st.Cc[VERASE] != 0x7f || st.Cc[VMIN] != 1 {
+		t.Errorf("wrong decoding: %+v", st)
+	}
+	if got := string(sttyEncode(&st)); got != text {
+		t.Errorf("expected to encode the same text\n got: %q\nwant: %q", got, text)
+	}
+	if n := len(strings.Split(text, ":")); n != 4+sttyNCCS {
+		t.Errorf("expected %d fields, got %d", 4+sttyNCCS, n)
+	}
+
+	for _, s := range []string{
+		"500:5:bf:8a3b",
+		strings.Replace(text, "500", "1ffffffff", 1),
+		strings.Replace(text, ":7f:", ":100:", 1),
+	} {
+		if err := sttyDecode(&st, s); err == nil {
+			t.Errorf("expected error at decoding %q", s)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStateText(t *testing.T) {
+	pty, err := OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	if err = pty.RawMode(); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := pty.CurrentState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := raw.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := pty.OriginalState()
+	if err = st.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if st.wrap != raw.wrap {
+		t.Errorf("expected to decode the same state\n got: %+v\nwant: %+v", st.wrap, raw.wrap)
+	}
+
+	if err = st.UnmarshalText([]byte("500:5:bf:8a3b")); err == nil {
+		t.Error("expected error at decoding a short state")
+	}
+
+	dump := pty.OriginalState().String()
+	for _, s := range []string{"speed ", "intr = ^C;", " icanon ", " echo "} {
+		if !strings.Contains(dump, s) {
+			t.Errorf("expected %q in the settings:\n%s", s, dump)
+		}
+	}
+	if dump = raw.String(); !strings.Contains(dump, " -icanon ") {
+		t.Errorf("expected %q in the settings:\n%s", " -icanon ", dump)
+	}
+}
+
+func TestSetByName(t *testing.T) {
+	var attr Attributes
+
+	for _, s := range []string{"echo", "icanon", "-icanon", "cs7", "ixon"} {
+		if err := attr.Set(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !attr.Lflag(ECHO) || attr.Lflag(ICANON) || !attr.Iflag(IXON) || attr.CharSize() != 7 {
+		t.Error("expected to set the modes")
+	}
+	if err := attr.Set("-bogus"); err == nil {
+		t.Error("expected error at setting an invalid mode")
+	}
+
+	chars := []struct {
+		name, value string
+		index       int
+		want        byte
+	}{
+		{"intr", "^C", VINTR, 3},
+		{"erase", "^?", VERASE, 127},
+		{"kill", "^u", VKILL, 21},
+		{"eof", "x", VEOF, 'x'},
+		{"eol", "undef", VEOL, vdisable},
+		{"min", "5", VMIN, 5},
+	}
+	for _, c := range chars {
+		if err := attr.SetChar(c.name, c.value); err != nil {
+			t.Fatal(err)
+		}
+		if got := attr.Cc(c.index); got != c.want {
+			t.Errorf("%s %s: expected %#x, got %#x", c.name, c.value, c.want, got)
+		}
+	}
+	if err := attr.SetChar("intr", "^1"); err == nil {
+		t.Error("expected error at setting an invalid control character")
+	}
+}
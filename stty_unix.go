@@ -0,0 +1,280 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+/* Reference: man stty */
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CurrentState returns the actual state of the terminal.
+func (t *Terminal) CurrentState() (State, error) {
+	var st State
+
+	if err := tcgetattr(t.fd, &st.wrap); err != nil {
+		return st, fmt.Errorf("terminal: could not get state: %s", err)
+	}
+	return st, nil
+}
+
+// MarshalText encodes the state in the format used by "stty -g" in the system.
+//
+// In Linux, it is the one of GNU stty: the input, output, control and local
+// modes, and the control characters, in hexadecimal and separated by colons.
+// In BSD systems, it is the one of BSD stty: "gfmt1" followed by the modes, the
+// control characters and the speed, like "cflag=4b00", separated by colons.
+func (s State) MarshalText() ([]byte, error) {
+	return sttyEncode(&s.wrap), nil
+}
+
+// UnmarshalText decodes the state from the format used by "stty -g" in the
+// system.
+//
+// Like in stty, the fields which are not in that format are not changed, so
+// the state should be got from the terminal before of decoding. In Linux, it
+// is the case of the speed, when it is not a standard one.
+func (s *State) UnmarshalText(text []byte) error {
+	if err := sttyDecode(&s.wrap, string(text)); err != nil {
+		return fmt.Errorf("terminal: invalid state: %q", text)
+	}
+	return nil
+}
+
+// String returns the settings in a human-readable form, like "stty -a".
+// The speed is omitted if it is not a standard one.
+func (s State) String() string {
+	var b bytes.Buffer
+	st := &s.wrap
+	attr := s.Attributes()
+
+	if _, speed := getSpeed(st); speed != -1 {
+		fmt.Fprintf(&b, "speed %d baud;\n", speed)
+	}
+
+	for i, c := range sttyChars {
+		if i != 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s = %s;", c.name, visibleChar(st.Cc[c.index]))
+	}
+	fmt.Fprintf(&b, " min = %d; time = %d;\n", st.Cc[VMIN], st.Cc[VTIME])
+
+	for _, field := range []byte{'c', 'i', 'o', 'l'} {
+		sep := ""
+
+		for _, m := range sttyModes {
+			if m.field != field {
+				continue
+			}
+			b.WriteString(sep)
+			if *m.flags(st)&m.mask == 0 {
+				b.WriteByte('-')
+			}
+			b.WriteString(m.name)
+			sep = " "
+
+			if m.name == "parodd" {
+				fmt.Fprintf(&b, " cs%d", attr.CharSize())
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Attributes returns the attributes stored in the state.
+func (s State) Attributes() Attributes {
+	return Attributes{s.wrap}
+}
+
+// == Names
+//
+
+// Set sets or clears a mode named as in stty, i.e. "echo" sets the echo and
+// "-echo" clears it. The character size is set by "cs5" to "cs8".
+func (a *Attributes) Set(setting string) error {
+	switch setting {
+	case "cs5", "cs6", "cs7", "cs8":
+		return a.SetCharSize(int(setting[2] - '0'))
+	}
+
+	name := strings.TrimPrefix(setting, "-")
+	for _, m := range sttyModes {
+		if m.name == name {
+			setFlag(m.flags(&a.wrap), uint(m.mask), name == setting, nil)
+			return nil
+		}
+	}
+	return fmt.Errorf("terminal: invalid setting: %q", setting)
+}
+
+// SetChar sets the control character named as in stty, i.e. "intr", from its
+// value in caret notation, i.e. "^C". The value "undef" or "^-" disables it.
+// The values of "min" and "time" are decimal numbers.
+func (a *Attributes) SetChar(name, value string) error {
+	if name == "min" || name == "time" {
+		n, err := strconv.ParseUint(value, 10, 8)
+		if err != nil {
+			return fmt.Errorf("terminal: invalid value for %s: %q", name, value)
+		}
+		if name == "min" {
+			a.wrap.Cc[VMIN] = byte(n)
+		} else {
+			a.wrap.Cc[VTIME] = byte(n)
+		}
+		return nil
+	}
+
+	for _, c := range sttyChars {
+		if c.name == name {
+			v, err := parseChar(value)
+			if err != nil {
+				return err
+			}
+			a.wrap.Cc[c.index] = v
+			return nil
+		}
+	}
+	return fmt.Errorf("terminal: invalid control character: %q", name)
+}
+
+// A sttyMode represents a mode which is set or cleared in stty by its name.
+type sttyMode struct {
+	name  string
+	field byte // input (i), output (o), control (c) or local (l) modes
+	mask  tcflag
+}
+
+// flags returns the field of modes in the termios structure.
+func (m sttyMode) flags(st *termios) *tcflag {
+	switch m.field {
+	case 'i':
+		return &st.Iflag
+	case 'o':
+		return &st.Oflag
+	case 'c':
+		return &st.Cflag
+	}
+	return &st.Lflag
+}
+
+var sttyModes = []sttyMode{
+	// Control modes
+	{"parenb", 'c', PARENB},
+	{"parodd", 'c', PARODD},
+	{"hupcl", 'c', HUPCL},
+	{"cstopb", 'c', CSTOPB},
+	{"cread", 'c', CREAD},
+	{"clocal", 'c', CLOCAL},
+	{"crtscts", 'c', CRTSCTS},
+
+	// Input modes
+	{"ignbrk", 'i', IGNBRK},
+	{"brkint", 'i', BRKINT},
+	{"ignpar", 'i', IGNPAR},
+	{"parmrk", 'i', PARMRK},
+	{"inpck", 'i', INPCK},
+	{"istrip", 'i', ISTRIP},
+	{"inlcr", 'i', INLCR},
+	{"igncr", 'i', IGNCR},
+	{"icrnl", 'i', ICRNL},
+	{"ixon", 'i', IXON},
+	{"ixoff", 'i', IXOFF},
+	{"ixany", 'i', IXANY},
+	{"imaxbel", 'i', IMAXBEL},
+
+	// Output modes
+	{"opost", 'o', OPOST},
+	{"ocrnl", 'o', OCRNL},
+	{"onlcr", 'o', ONLCR},
+	{"onocr", 'o', ONOCR},
+	{"onlret", 'o', ONLRET},
+
+	// Local modes
+	{"isig", 'l', ISIG},
+	{"icanon", 'l', ICANON},
+	{"iexten", 'l', IEXTEN},
+	{"echo", 'l', ECHO},
+	{"echoe", 'l', ECHOE},
+	{"echok", 'l', ECHOK},
+	{"echonl", 'l', ECHONL},
+	{"noflsh", 'l', NOFLSH},
+	{"tostop", 'l', TOSTOP},
+	{"echoprt", 'l', ECHOPRT},
+	{"echoctl", 'l', ECHOCTL},
+	{"echoke", 'l', ECHOKE},
+	{"flusho", 'l', FLUSHO},
+	{"extproc", 'l', EXTPROC},
+}
+
+// A sttyChar represents a control character named in stty.
+type sttyChar struct {
+	name  string
+	index int
+}
+
+var sttyChars = []sttyChar{
+	{"intr", VINTR},
+	{"quit", VQUIT},
+	{"erase", VERASE},
+	{"kill", VKILL},
+	{"eof", VEOF},
+	{"eol", VEOL},
+	{"eol2", VEOL2},
+	{"start", VSTART},
+	{"stop", VSTOP},
+	{"susp", VSUSP},
+	{"rprnt", VREPRINT},
+	{"werase", VWERASE},
+	{"lnext", VLNEXT},
+	{"discard", VDISCARD},
+}
+
+// visibleChar returns a control character in the notation used by stty.
+func visibleChar(c byte) string {
+	if c == vdisable {
+		return "<undef>"
+	}
+	if c > 127 {
+		return "M-" + caretChar(c-128)
+	}
+	return caretChar(c)
+}
+
+// parseChar returns the control character from its notation used by stty.
+func parseChar(s string) (byte, error) {
+	switch {
+	case s == "undef" || s == "^-":
+		return vdisable, nil
+	case s == "^?":
+		return 127, nil
+	case len(s) == 2 && s[0] == '^':
+		if c := s[1] &^ 0x20; c >= '@' && c <= '_' { // upper case
+			return c - '@', nil
+		}
+	case len(s) == 1:
+		return s[0], nil
+	}
+	return 0, fmt.Errorf("terminal: invalid control character: %q", s)
+}
+
+// caretChar returns an ASCII character using the caret notation for the
+// control characters.
+func caretChar(c byte) string {
+	switch {
+	case c == 127:
+		return "^?"
+	case c < 32:
+		return "^" + string(c+'@')
+	}
+	return string(c)
+}
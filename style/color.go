@@ -0,0 +1,184 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package style
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kless/terminal"
+)
+
+type colorMode uint8
+
+const (
+	modeDefault colorMode = iota
+	modeANSI              // 16 colors
+	modeIndex             // 256 colors
+	modeRGB               // 24-bit colors
+)
+
+// A Color represents a color of the text or of the background. The zero value
+// is the default color of the terminal.
+type Color struct {
+	mode    colorMode
+	n       uint8 // index, in modes ANSI and Index
+	r, g, b uint8
+}
+
+// The 16 colors of the ANSI palette, whose values depend on the terminal.
+var (
+	Black   = ANSI(0)
+	Red     = ANSI(1)
+	Green   = ANSI(2)
+	Yellow  = ANSI(3)
+	Blue    = ANSI(4)
+	Magenta = ANSI(5)
+	Cyan    = ANSI(6)
+	White   = ANSI(7)
+
+	BrightBlack   = ANSI(8)
+	BrightRed     = ANSI(9)
+	BrightGreen   = ANSI(10)
+	BrightYellow  = ANSI(11)
+	BrightBlue    = ANSI(12)
+	BrightMagenta = ANSI(13)
+	BrightCyan    = ANSI(14)
+	BrightWhite   = ANSI(15)
+)
+
+// ANSI returns the color of the palette of 16 colors, from 0 to 15.
+func ANSI(n uint8) Color {
+	return Color{mode: modeANSI, n: n & 15}
+}
+
+// Index returns the color of the palette of 256 colors, where the first 16
+// ones are the ANSI colors, followed by a cube of 6x6x6 colors and a scale of
+// 24 grays.
+func Index(n uint8) Color {
+	return Color{mode: modeIndex, n: n}
+}
+
+// RGB returns a 24-bit color.
+func RGB(r, g, b uint8) Color {
+	return Color{mode: modeRGB, r: r, g: g, b: b}
+}
+
+// Hex returns the 24-bit color in hexadecimal notation, "#rrggbb" or "#rgb".
+func Hex(s string) (Color, error) {
+	if len(s) == 0 || s[0] != '#' || len(s) != 4 && len(s) != 7 {
+		return Color{}, fmt.Errorf("style: invalid color: %q", s)
+	}
+
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("style: invalid color: %q", s)
+	}
+	if len(s) == 4 {
+		r, g, b := uint8(v>>8), uint8(v>>4&15), uint8(v&15)
+		return RGB(r*17, g*17, b*17), nil
+	}
+	return RGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+}
+
+// IsDefault reports whether it is the default color of the terminal.
+func (c Color) IsDefault() bool {
+	return c.mode == modeDefault
+}
+
+// Downgrade returns the nearest color which can be represented in the depth.
+func (c Color) Downgrade(d Depth) Color {
+	switch {
+	case c.mode == modeDefault:
+		return c
+	case d < Color16:
+		return Color{}
+	case d == Color16 && c.mode > modeANSI:
+		return ANSI(nearest(c.rgb(), 16))
+	case d == Color256 && c.mode == modeRGB:
+		return Index(nearest(c.rgb(), 256))
+	}
+	return c
+}
+
+// sgr returns the parameters of SGR for the color of text, or of background if
+// bg is set.
+func (c Color) sgr(bg bool) string {
+	base := 30
+	if bg {
+		base = 40
+	}
+
+	switch c.mode {
+	case modeANSI:
+		if c.n >= 8 {
+			return strconv.Itoa(base + 60 + int(c.n) - 8)
+		}
+		return strconv.Itoa(base + int(c.n))
+	case modeIndex:
+		return strconv.Itoa(base+8) + ";5;" + strconv.Itoa(int(c.n))
+	case modeRGB:
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, c.r, c.g, c.b)
+	}
+	return strconv.Itoa(base + 9)
+}
+
+// == Palette
+//
+
+// cubeLevels are the values of each channel in the cube of 6x6x6 colors.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// paletteRGB returns the value of a color of the palette of 256 colors.
+func paletteRGB(n uint8) [3]uint8 {
+	switch {
+	case n < 16:
+		c := terminal.ANSIColors[n]
+		return [3]uint8{uint8(c.R >> 8), uint8(c.G >> 8), uint8(c.B >> 8)}
+	case n < 232:
+		n -= 16
+		return [3]uint8{cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]}
+	}
+	v := 8 + 10*(n-232)
+	return [3]uint8{v, v, v}
+}
+
+// rgb returns the value of the color.
+func (c Color) rgb() [3]uint8 {
+	if c.mode == modeRGB {
+		return [3]uint8{c.r, c.g, c.b}
+	}
+	return paletteRGB(c.n)
+}
+
+// nearest returns the index of the nearest color in the palette of size colors,
+// 16 or 256. In the palette of 256 colors, the 16 ANSI colors are not used
+// since their values depend on the terminal.
+func nearest(rgb [3]uint8, size int) uint8 {
+	start := 0
+	if size == 256 {
+		start = 16
+	}
+
+	best, bestDist := start, -1
+	for i := start; i < size; i++ {
+		p := paletteRGB(uint8(i))
+		if d := distance(rgb, p); bestDist == -1 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return uint8(best)
+}
+
+// distance returns the distance between two colors, weighted by the
+// sensitivity of the eye to each channel.
+func distance(a, b [3]uint8) int {
+	dr := int(a[0]) - int(b[0])
+	dg := int(a[1]) - int(b[1])
+	db := int(a[2]) - int(b[2])
+	return 3*dr*dr + 4*dg*dg + 2*db*db
+}
@@ -0,0 +1,130 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package style
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kless/terminal"
+	"github.com/kless/terminal/terminfo"
+)
+
+func TestDetect(t *testing.T) {
+	os.Setenv("TERMINFO", "../terminfo/testdata")
+	defer os.Unsetenv("TERMINFO")
+
+	ti8, err := terminfo.Load("gotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tiRGB, err := terminfo.Load("gotest-direct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		env    map[string]string
+		isTerm bool
+		ti     *terminfo.Terminfo
+		want   Depth
+	}{
+		{nil, false, ti8, NoStyle},
+		{nil, true, ti8, Color16},
+		{nil, true, tiRGB, TrueColor},
+		{map[string]string{"TERM": "dumb"}, true, nil, NoColor},
+		{map[string]string{"TERM": "xterm-256color"}, true, nil, Color256},
+		{map[string]string{"TERM": "xterm"}, true, nil, Color16},
+		{map[string]string{"COLORTERM": "truecolor"}, true, ti8, TrueColor},
+		{map[string]string{"COLORTERM": "24bit"}, true, nil, TrueColor},
+
+		{map[string]string{"NO_COLOR": "1"}, true, tiRGB, NoColor},
+		{map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, false, ti8, NoStyle},
+		{map[string]string{"NO_COLOR": ""}, true, ti8, Color16},
+		{map[string]string{"CLICOLOR_FORCE": "1"}, false, ti8, Color16},
+		{map[string]string{"CLICOLOR_FORCE": "0"}, false, ti8, NoStyle},
+		{map[string]string{"CLICOLOR_FORCE": "1", "TERM": "dumb"}, false, nil, Color16},
+	}
+
+	for i, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := detect(getenv, tt.isTerm, tt.ti); got != tt.want {
+			t.Errorf("#%d: expected %d, got %d", i, tt.want, got)
+		}
+	}
+}
+
+func TestQueryRGB(t *testing.T) {
+	pty, err := terminal.OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	terminal.QueryTimeout = 200 * time.Millisecond
+	defer func() { terminal.QueryTimeout = 1 * time.Second }()
+
+	replies := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			if _, err := pty.Master.Read(buf); err != nil {
+				return
+			}
+			pty.Master.Write([]byte(<-replies))
+		}
+	}()
+
+	for _, tt := range []struct {
+		reply string
+		want  bool
+	}{
+		{"\033P1+r524742=382F382F38\033\\\033[?62c", true},
+		{"\033P0+r524742\033\\\033[?62c", false},
+		{"\033[?1;2c", false},
+	} {
+		replies <- tt.reply
+		if got := queryRGB(pty.Terminal); got != tt.want {
+			t.Errorf("%q: expected %t", tt.reply, tt.want)
+		}
+	}
+}
+
+func TestDetectEnv(t *testing.T) {
+	pty, err := terminal.OpenPTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pty.Close()
+
+	for _, k := range []string{"TERMINFO", "TERM", "COLORTERM", "NO_COLOR", "CLICOLOR_FORCE"} {
+		defer os.Setenv(k, os.Getenv(k))
+		os.Unsetenv(k)
+	}
+	os.Setenv("TERMINFO", "../terminfo/testdata")
+	os.Setenv("TERM", "gotest")
+
+	requests := make(chan bool, 1)
+	go func() {
+		buf := make([]byte, 64)
+		if _, err := pty.Master.Read(buf); err == nil {
+			requests <- true
+		}
+	}()
+
+	if d := DetectEnv(pty.Fd()); d != Color16 {
+		t.Errorf("expected %d, got %d", Color16, d)
+	}
+	select {
+	case <-requests:
+		t.Error("expected to not request anything to the terminal")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build !plan9,!windows
+
+package style
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/kless/terminal"
+	"github.com/kless/terminal/terminfo"
+)
+
+// Detect returns the depth of colors supported by the terminal in the file
+// descriptor. It is got, in order, from:
+//
+//   NO_COLOR, which disables the colors when it is set
+//   CLICOLOR_FORCE, which enables the colors when it is not "0", although
+//     the output is not a terminal
+//   COLORTERM, set to "truecolor" or "24bit" by terminals with 24-bit colors
+//   the terminfo entry of TERM, through the capabilities "RGB", "Tc" and
+//     "colors"
+//   the reply of the terminal to the request of the capability "RGB" through
+//     XTGETTCAP, when it is a terminal
+func Detect(fd int) Depth {
+	d := DetectEnv(fd)
+	if d >= Color16 && d < TrueColor && terminal.IsTerminal(fd) {
+		if t, err := terminal.New(fd); err == nil && queryRGB(t) {
+			d = TrueColor
+		}
+	}
+	return d
+}
+
+// DetectEnv returns the depth of colors like Detect, but without requesting
+// anything to the terminal, so it does not wait for a reply.
+func DetectEnv(fd int) Depth {
+	var ti *terminfo.Terminfo
+	if term := os.Getenv("TERM"); term != "" && term != "dumb" {
+		ti, _ = terminfo.Load(term)
+	}
+	return detect(os.Getenv, terminal.IsTerminal(fd), ti)
+}
+
+// detect returns the depth of colors got from the environment and from the
+// terminfo entry, which can be nil.
+func detect(getenv func(string) string, isTerm bool, ti *terminfo.Terminfo) Depth {
+	// Both variables are ignored when they are empty.
+	if getenv("NO_COLOR") != "" {
+		if !isTerm {
+			return NoStyle
+		}
+		return NoColor
+	}
+
+	force := getenv("CLICOLOR_FORCE") != "" && getenv("CLICOLOR_FORCE") != "0"
+	if !isTerm && !force {
+		return NoStyle
+	}
+
+	term := getenv("TERM")
+	if term == "dumb" && !force {
+		return NoColor
+	}
+
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+
+	if ti != nil {
+		colors := ti.Number("colors")
+		switch {
+		case ti.Bool("RGB") || ti.Bool("Tc") || colors >= 1<<24:
+			return TrueColor
+		case colors >= 256:
+			return Color256
+		case colors >= 8:
+			return Color16
+		}
+		if !force {
+			return NoColor
+		}
+		return Color16
+	}
+
+	if strings.Contains(term, "256color") {
+		return Color256
+	}
+	return Color16
+}
+
+// queryRGB reports whether the terminal has the capability "RGB", requested
+// through XTGETTCAP. The primary device attributes are requested after, so it
+// does not wait for the timeout when that sequence is not supported.
+func queryRGB(t *terminal.Terminal) bool {
+	var found bool
+
+	// "RGB" in hexadecimal
+	t.Query("\033P+q524742\033\\\033[c", terminal.QueryTimeout,
+		func(seq []byte) (bool, bool) {
+			if bytes.HasPrefix(seq, []byte("\033P1+r524742")) {
+				found = true
+				return true, false
+			}
+			if bytes.HasPrefix(seq, []byte("\033P0+r")) {
+				return true, false
+			}
+			if len(seq) > 3 && seq[1] == '[' && seq[2] == '?' && seq[len(seq)-1] == 'c' {
+				return true, true
+			}
+			return false, false
+		})
+	return found
+}
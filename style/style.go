@@ -0,0 +1,174 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: http://invisible-island.net/xterm/ctlseqs/ctlseqs.html */
+
+// Package style builds the escape sequences which set the colors and the
+// attributes of the text (SGR, Select Graphic Rendition).
+//
+// A style is built by chaining its settings:
+//
+//   s := style.New().Bold().Fg(style.RGB(255, 135, 0))
+//   fmt.Println(s.Render(style.Detect(syscall.Stdout), "warning"))
+//
+// The colors are downgraded to the nearest ones supported by the terminal,
+// whose depth of colors is detected from the environment, the terminfo database
+// and queries to the terminal. The variables NO_COLOR and CLICOLOR_FORCE are
+// respected.
+package style
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Depth represents the depth of colors supported by a terminal.
+type Depth int
+
+const (
+	NoStyle   Depth = iota // no escape sequences; the output is not a terminal
+	NoColor                // attributes but no colors
+	Color16                // the palette of 16 colors
+	Color256               // the palette of 256 colors
+	TrueColor              // 24-bit colors
+)
+
+// Attr represents attributes of the text.
+type Attr uint
+
+const (
+	Bold Attr = 1 << iota
+	Faint
+	Italic
+	Blink
+	Reverse
+	Hidden
+	Strikethrough
+)
+
+// attrCodes are the parameters of SGR for each attribute.
+var attrCodes = []struct {
+	attr Attr
+	code int
+}{
+	{Bold, 1},
+	{Faint, 2},
+	{Italic, 3},
+	{Blink, 5},
+	{Reverse, 7},
+	{Hidden, 8},
+	{Strikethrough, 9},
+}
+
+// Underline represents the style of underline.
+type Underline uint8
+
+const (
+	NoUnderline Underline = iota
+	UnderlineSingle
+	UnderlineDouble
+	UnderlineCurly
+	UnderlineDotted
+	UnderlineDashed
+)
+
+// Reset is the sequence which resets all attributes and colors.
+const Reset = "\033[0m"
+
+// A Style represents the colors and attributes of the text.
+// The zero value is the default style of the terminal.
+type Style struct {
+	fg, bg    Color
+	attr      Attr
+	underline Underline
+}
+
+// New returns the default style, to be built through its methods.
+func New() Style { return Style{} }
+
+// Fg returns the style with the color of the text.
+func (s Style) Fg(c Color) Style {
+	s.fg = c
+	return s
+}
+
+// Bg returns the style with the color of the background.
+func (s Style) Bg(c Color) Style {
+	s.bg = c
+	return s
+}
+
+// Attr returns the style with the attributes added.
+func (s Style) Attr(a Attr) Style {
+	s.attr |= a
+	return s
+}
+
+// Bold returns the style in bold.
+func (s Style) Bold() Style { return s.Attr(Bold) }
+
+// Faint returns the style in faint, or dim.
+func (s Style) Faint() Style { return s.Attr(Faint) }
+
+// Italic returns the style in italic.
+func (s Style) Italic() Style { return s.Attr(Italic) }
+
+// Reverse returns the style with the colors of text and background swapped.
+func (s Style) Reverse() Style { return s.Attr(Reverse) }
+
+// Strikethrough returns the style with the text crossed out.
+func (s Style) Strikethrough() Style { return s.Attr(Strikethrough) }
+
+// Underline returns the style with the text underlined. The styles other than
+// UnderlineSingle are set with the extension of kitty, supported by several
+// terminals; the rest ones show a single underline.
+func (s Style) Underline(u Underline) Style {
+	s.underline = u
+	return s
+}
+
+// Sequence returns the escape sequence which sets the style in a terminal with
+// the depth of colors. It is empty for the default style, or in NoStyle.
+func (s Style) Sequence(d Depth) string {
+	if d == NoStyle {
+		return ""
+	}
+	var params []string
+
+	for _, a := range attrCodes {
+		if s.attr&a.attr != 0 {
+			params = append(params, strconv.Itoa(a.code))
+		}
+	}
+	switch s.underline {
+	case NoUnderline:
+	case UnderlineSingle:
+		params = append(params, "4")
+	default:
+		params = append(params, "4:"+strconv.Itoa(int(s.underline)))
+	}
+
+	if fg := s.fg.Downgrade(d); !fg.IsDefault() {
+		params = append(params, fg.sgr(false))
+	}
+	if bg := s.bg.Downgrade(d); !bg.IsDefault() {
+		params = append(params, bg.sgr(true))
+	}
+
+	if len(params) == 0 {
+		return ""
+	}
+	return "\033[" + strings.Join(params, ";") + "m"
+}
+
+// Render returns the text with the style set, and reset at the end.
+func (s Style) Render(d Depth, text string) string {
+	seq := s.Sequence(d)
+	if seq == "" {
+		return text
+	}
+	return seq + text + Reset
+}
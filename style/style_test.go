@@ -0,0 +1,75 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package style
+
+import "testing"
+
+func TestSequence(t *testing.T) {
+	orange := RGB(255, 135, 0)
+
+	tests := []struct {
+		style Style
+		depth Depth
+		want  string
+	}{
+		{New(), TrueColor, ""},
+		{New().Bold(), NoStyle, ""},
+		{New().Bold(), NoColor, "\033[1m"},
+		{New().Bold().Fg(Yellow), NoColor, "\033[1m"},
+		{New().Fg(Yellow), NoColor, ""},
+
+		{New().Bold().Fg(Yellow), Color16, "\033[1;33m"},
+		{New().Fg(BrightBlue).Bg(Black), Color16, "\033[94;40m"},
+		{New().Fg(Index(208)), Color256, "\033[38;5;208m"},
+		{New().Bg(orange), TrueColor, "\033[48;2;255;135;0m"},
+		{New().Italic().Strikethrough().Underline(UnderlineSingle), Color16, "\033[3;9;4m"},
+		{New().Underline(UnderlineCurly), Color16, "\033[4:3m"},
+
+		// Downgrade
+		{New().Fg(orange), Color256, "\033[38;5;208m"},
+		{New().Fg(orange), Color16, "\033[33m"},
+		{New().Fg(Index(208)), Color16, "\033[33m"},
+		{New().Bg(RGB(0x80, 0x80, 0x80)), Color256, "\033[48;5;244m"},
+		{New().Fg(RGB(10, 10, 10)), Color16, "\033[30m"},
+	}
+
+	for i, tt := range tests {
+		if got := tt.style.Sequence(tt.depth); got != tt.want {
+			t.Errorf("#%d: expected %q, got %q", i, tt.want, got)
+		}
+	}
+
+	if got := New().Bold().Render(Color16, "a"); got != "\033[1ma\033[0m" {
+		t.Errorf("Render: got %q", got)
+	}
+	if got := New().Fg(Red).Render(NoColor, "a"); got != "a" {
+		t.Errorf("Render: got %q", got)
+	}
+}
+
+func TestHex(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want Color
+	}{
+		{"#ff8700", RGB(255, 135, 0)},
+		{"#F80", RGB(255, 136, 0)},
+	} {
+		c, err := Hex(tt.in)
+		if err != nil {
+			t.Errorf("%q: %s", tt.in, err)
+		} else if c != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.in, tt.want, c)
+		}
+	}
+
+	for _, in := range []string{"", "ff8700", "#ff870", "#gg8700"} {
+		if _, err := Hex(in); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
@@ -46,6 +46,15 @@ func tcsetattr(fd int, action uint, state *termios) (err error) {
 	return
 }
 
+// ioctl manipulates the underlying device parameters of the file descriptor.
+func ioctl(fd int, request uint, arg uintptr) (err error) {
+	_, _, e1 := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(request), arg)
+	if e1 != 0 {
+		err = e1
+	}
+	return
+}
+
 // getWinsize gets the winsize struct with the terminal size set by the kernel.
 func getWinsize(fd int, ws *winsize) (err error) {
 	_, _, e1 := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd),
@@ -55,3 +64,13 @@ func getWinsize(fd int, ws *winsize) (err error) {
 	}
 	return
 }
+
+// setWinsize sets the terminal size through the winsize struct.
+func setWinsize(fd int, ws *winsize) (err error) {
+	_, _, e1 := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd),
+		uintptr(_TIOCSWINSZ), uintptr(unsafe.Pointer(ws)))
+	if e1 != 0 {
+		err = e1
+	}
+	return
+}
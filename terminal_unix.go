@@ -10,18 +10,25 @@ package terminal
 
 import (
 	"fmt"
+	"sync"
+
+	"github.com/kless/terminal/ansi"
 )
 
 // A Terminal represents a general terminal interface.
 type Terminal struct {
-	fd int // File descriptor
+	fd  int // File descriptor
 	mod mode
 
-	// Size
-	row, column int
-
 	// Contain the state of a terminal, allowing to restore the original settings
 	oldState, lastState termios
+
+	privModes    []ansi.Mode // DEC private modes set, to be reset by Restore
+	cursorHidden bool        // the cursor is hidden, to be shown by Restore
+	typeahead    []byte      // input read by Query, which is not part of a reply
+
+	jobMu sync.Mutex
+	jobs  *JobControl // catching SIGTSTP
 }
 
 // New creates a new terminal interface in the file descriptor.
@@ -51,14 +58,39 @@ func (t *Terminal) OriginalState() State {
 	return State{t.oldState}
 }
 
-// Restore restores the original settings for the terminal.
+// Restore restores the original settings for the terminal, and resets the modes
+// set through escape sequences, like the mouse reports and the alternate
+// screen.
+//
+// All of them are tried, so the settings are restored although the modes can
+// not be written, and it is returned the first error.
 func (t *Terminal) Restore() error {
+	var err error
+
+	if t.cursorHidden {
+		if e := t.ShowCursor(); e != nil && err == nil {
+			err = e
+		}
+	}
+	if len(t.privModes) != 0 {
+		if e := t.resetPrivateModes(); e != nil && err == nil {
+			err = e
+		}
+	}
+
 	if t.mod != 0 {
-		if err := tcsetattr(t.fd, _TCSANOW, &t.oldState); err != nil {
-			return fmt.Errorf("terminal: could not restore: %s", err)
+		if e := tcsetattr(t.fd, _TCSANOW, &t.oldState); e != nil {
+			if err == nil {
+				err = e
+			}
+		} else {
+			t.lastState = t.oldState
+			t.mod = 0
 		}
-		t.lastState = t.oldState
-		t.mod = 0
+	}
+
+	if err != nil {
+		return fmt.Errorf("terminal: could not restore: %s", err)
 	}
 	return nil
 }
@@ -83,33 +115,37 @@ func (t *Terminal) RawMode() error {
 	if t.mod&rawMode != 0 {
 		return nil
 	}
+	makeRaw(&t.lastState)
+
+	// Put the terminal in raw mode after flushing
+	if err := tcsetattr(t.fd, _TCSAFLUSH, &t.lastState); err != nil {
+		return fmt.Errorf("terminal: could not set raw mode: %s", err)
+	}
+	t.mod |= rawMode
+	return nil
+}
 
+// makeRaw sets the state to the raw mode.
+func makeRaw(st *termios) {
 	// Input modes - no break, no CR to NL, no NL to CR, no carriage return,
 	// no strip char, no start/stop output control, no parity check.
-	t.lastState.Iflag &^= (BRKINT | IGNBRK | ICRNL | INLCR | IGNCR | ISTRIP | IXON | PARMRK)
+	st.Iflag &^= (BRKINT | IGNBRK | ICRNL | INLCR | IGNCR | ISTRIP | IXON | PARMRK)
 
 	// Output modes - disable post processing.
-	t.lastState.Oflag &^= OPOST
+	st.Oflag &^= OPOST
 
 	// Local modes - echoing off, canonical off, no extended functions,
 	// no signal chars (^Z,^C).
-	t.lastState.Lflag &^= (ECHO | ECHONL | ICANON | IEXTEN | ISIG)
+	st.Lflag &^= (ECHO | ECHONL | ICANON | IEXTEN | ISIG)
 
 	// Control modes - set 8 bit chars.
-	t.lastState.Cflag &^= (CSIZE | PARENB)
-	t.lastState.Cflag |= CS8
+	st.Cflag &^= (CSIZE | PARENB)
+	st.Cflag |= CS8
 
 	// Control chars - set return condition: min number of bytes and timer.
 	// We want read to return every single byte, without timeout.
-	t.lastState.Cc[VMIN] = 1 // Read returns when one char is available.
-	t.lastState.Cc[VTIME] = 0
-
-	// Put the terminal in raw mode after flushing
-	if err := tcsetattr(t.fd, _TCSAFLUSH, &t.lastState); err != nil {
-		return fmt.Errorf("terminal: could not set raw mode: %s", err)
-	}
-	t.mod |= rawMode
-	return nil
+	st.Cc[VMIN] = 1 // Read returns when one char is available.
+	st.Cc[VTIME] = 0
 }
 
 // EchoMode turns the echo mode.
@@ -168,18 +204,54 @@ func (t *Terminal) Fd() int {
 
 // GetSize returns the size of the terminal.
 func (t *Terminal) GetSize() (row, column int, err error) {
-/*fmt.Println(t.row)
-	if t.row != 0 {
-println("OPS!")
-		return t.row, t.column, nil
+	ws := new(winsize)
+
+	if err = getWinsize(t.fd, ws); err != nil {
+		return
 	}
-*/
+	return int(ws.Row), int(ws.Col), nil
+}
+
+// GetSizePixels returns the size of the terminal in pixels.
+// Note that it is zero when the terminal does not report it.
+func (t *Terminal) GetSizePixels() (xpixel, ypixel int, err error) {
 	ws := new(winsize)
 
-	if e := getWinsize(t.fd, ws); e != nil {
-		err = e
+	if err = getWinsize(t.fd, ws); err != nil {
 		return
 	}
-	t.row, t.column = int(ws.Row), int(ws.Col)
-	return t.row, t.column, nil
+	return int(ws.Xpixel), int(ws.Ypixel), nil
+}
+
+// CellSize returns the size in pixels of a character cell.
+// Note that it is zero when the terminal does not report its size in pixels.
+func (t *Terminal) CellSize() (width, height int, err error) {
+	ws := new(winsize)
+
+	if err = getWinsize(t.fd, ws); err != nil {
+		return
+	}
+	if ws.Row == 0 || ws.Col == 0 {
+		return
+	}
+	return int(ws.Xpixel / ws.Col), int(ws.Ypixel / ws.Row), nil
+}
+
+// SetSize sets the size of the terminal, in characters and in pixels.
+// The pixel dimensions are not used by the kernel, so they can be zero.
+//
+// The foreground process group of the terminal gets a SIGWINCH signal when the
+// size changes.
+func (t *Terminal) SetSize(row, column, xpixel, ypixel int) error {
+	ws := &winsize{
+		Row:    uint16(row),
+		Col:    uint16(column),
+		Xpixel: uint16(xpixel),
+		Ypixel: uint16(ypixel),
+	}
+
+	if err := setWinsize(t.fd, ws); err != nil {
+		return fmt.Errorf("terminal: could not set size: %s", err)
+	}
+	return nil
 }
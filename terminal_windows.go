@@ -24,9 +24,6 @@ type Terminal struct {
 	handle syscall.Handle
 	mod mode
 
-	// Size
-	row, column int
-
 	// Contain the state of a terminal, allowing to restore the original settings
 	oldState, lastState uint32
 }
@@ -189,16 +186,11 @@ func (t *Terminal) Fd() int {
 
 // GetSize returns the size of the terminal.
 func (t *Terminal) GetSize() (row, column int, err error) {
-/*	if t.row != 0 {
-		return t.row, t.column, nil
-	}
-*/
 	info := new(_CONSOLE_SCREEN_BUFFER_INFO)
 
 	if e := getConsoleScreenBufferInfo(t.handle, info); e != nil {
 		err = os.NewSyscallError("getConsoleScreenBufferInfo", e)
 		return
 	}
-	t.row, t.column = int(info.dwSize.x), int(info.dwSize.y)
-	return t.row, t.column, nil
+	return int(info.dwSize.x), int(info.dwSize.y), nil
 }
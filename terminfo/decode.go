@@ -0,0 +1,245 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package terminfo
+
+import (
+	"errors"
+	"strings"
+)
+
+// Magic numbers of the compiled entries.
+const (
+	magicLegacy = 0432  // numbers of 16 bits
+	magic32bit  = 01036 // numbers of 32 bits, since ncurses 6.1
+)
+
+var errFormat = errors.New("invalid format")
+
+// decoder reads the little-endian values of a compiled entry.
+type decoder struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil || n < 0 || d.pos+n > len(d.data) {
+		d.err = errFormat
+		return nil
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+// short returns a signed integer of 16 bits.
+func (d *decoder) short() int {
+	b := d.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return int(int16(uint16(b[0]) | uint16(b[1])<<8))
+}
+
+// number returns a signed integer of 16 or 32 bits.
+func (d *decoder) number(size int) int {
+	if size == 2 {
+		return d.short()
+	}
+	b := d.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return int(int32(uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24))
+}
+
+// align skips the null byte used to put the next section in an even offset.
+func (d *decoder) align() {
+	if d.pos%2 != 0 {
+		d.bytes(1)
+	}
+}
+
+// Parse decodes a compiled entry.
+func Parse(data []byte) (*Terminfo, error) {
+	d := &decoder{data: data}
+	ti := &Terminfo{
+		Bools:   make(map[string]bool),
+		Numbers: make(map[string]int),
+		Strings: make(map[string]string),
+	}
+
+	numSize := 2
+	switch d.short() {
+	case magicLegacy:
+	case magic32bit:
+		numSize = 4
+	default:
+		return nil, errFormat
+	}
+
+	namesSize := d.short()
+	boolCount := d.short()
+	numCount := d.short()
+	strCount := d.short()
+	tableSize := d.short()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if namesSize < 0 || boolCount < 0 || numCount < 0 || strCount < 0 || tableSize < 0 {
+		return nil, errFormat
+	}
+	if boolCount > len(boolNames) || numCount > len(numNames) || strCount > len(stringNames) {
+		return nil, errFormat
+	}
+
+	names := d.bytes(namesSize)
+	ti.Names = strings.Split(cstring(names), "|")
+
+	for i, b := range d.bytes(boolCount) {
+		if b == 1 {
+			ti.Bools[boolNames[i]] = true
+		}
+	}
+	d.align()
+
+	for i := 0; i < numCount; i++ {
+		if n := d.number(numSize); n >= 0 {
+			ti.Numbers[numNames[i]] = n
+		}
+	}
+
+	offsets := make([]int, strCount)
+	for i := range offsets {
+		offsets[i] = d.short()
+	}
+	table := d.bytes(tableSize)
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	for i, off := range offsets {
+		if off < 0 {
+			continue // absent or cancelled
+		}
+		if off >= len(table) {
+			return nil, errFormat
+		}
+		ti.Strings[stringNames[i]] = cstring(table[off:])
+	}
+
+	// Extended capabilities.
+	d.align()
+	if d.pos >= len(d.data) {
+		return ti, nil
+	}
+	if err := d.extended(ti, numSize); err != nil {
+		return nil, err
+	}
+	return ti, nil
+}
+
+// extended decodes the section of capabilities defined by the user, whose names
+// are stored after the values of the strings.
+func (d *decoder) extended(ti *Terminfo, numSize int) error {
+	boolCount := d.short()
+	numCount := d.short()
+	strCount := d.short()
+	d.short() // number of items in the table
+	tableSize := d.short()
+	if d.err != nil {
+		return d.err
+	}
+	if boolCount < 0 || numCount < 0 || strCount < 0 {
+		return errFormat
+	}
+
+	bools := d.bytes(boolCount)
+	d.align()
+
+	nums := make([]int, numCount)
+	for i := range nums {
+		nums[i] = d.number(numSize)
+	}
+
+	offsets := make([]int, strCount)
+	for i := range offsets {
+		offsets[i] = d.short()
+	}
+	nameOffsets := make([]int, boolCount+numCount+strCount)
+	for i := range nameOffsets {
+		nameOffsets[i] = d.short()
+	}
+	table := d.bytes(tableSize)
+	if d.err != nil {
+		return d.err
+	}
+
+	// The names start after the last value.
+	values := make([]string, strCount)
+	namesStart := 0
+	for i, off := range offsets {
+		if off < 0 {
+			continue
+		}
+		if off >= len(table) {
+			return errFormat
+		}
+		values[i] = cstring(table[off:])
+
+		if end := off + len(values[i]) + 1; end > namesStart {
+			namesStart = end
+		}
+	}
+
+	name := func(i int) (string, error) {
+		off := namesStart + nameOffsets[i]
+		if nameOffsets[i] < 0 || off >= len(table) {
+			return "", errFormat
+		}
+		return cstring(table[off:]), nil
+	}
+
+	for i, b := range bools {
+		n, err := name(i)
+		if err != nil {
+			return err
+		}
+		if b == 1 {
+			ti.Bools[n] = true
+		}
+	}
+	for i, v := range nums {
+		n, err := name(boolCount + i)
+		if err != nil {
+			return err
+		}
+		if v >= 0 {
+			ti.Numbers[n] = v
+		}
+	}
+	for i, off := range offsets {
+		n, err := name(boolCount + numCount + i)
+		if err != nil {
+			return err
+		}
+		if off >= 0 {
+			ti.Strings[n] = values[i]
+		}
+	}
+	return nil
+}
+
+// cstring returns the string until the first null byte.
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,158 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: man 5 term, man 5 terminfo */
+
+// Package terminfo reads the terminfo database, which describes the
+// capabilities of the terminals.
+//
+// The compiled entries are read without cgo, both in the legacy format and in
+// the format with 32-bit numbers used by ncurses 6.1, including the extended
+// capabilities defined by the user.
+//
+// The capabilities are named by their short name in terminfo, i.e. "cup" is
+// the cursor addressing, and "colors" the number of colors.
+package terminfo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when there is not an entry for the terminal.
+var ErrNotFound = errors.New("terminfo: terminal not found")
+
+// Terminfo represents the entry of a terminal.
+type Terminfo struct {
+	// Names are the name of the terminal, its aliases and its description.
+	Names []string
+
+	Bools   map[string]bool
+	Numbers map[string]int
+	Strings map[string]string
+}
+
+// Bool reports whether the boolean capability is set.
+func (ti *Terminfo) Bool(name string) bool {
+	return ti.Bools[name]
+}
+
+// Number returns the value of the numeric capability, or -1 if it is not set.
+func (ti *Terminfo) Number(name string) int {
+	if n, ok := ti.Numbers[name]; ok {
+		return n
+	}
+	return -1
+}
+
+// String returns the value of the string capability, or an empty string if it
+// is not set.
+func (ti *Terminfo) String(name string) string {
+	return ti.Strings[name]
+}
+
+// Parm returns the value of the string capability, with its parameters expanded
+// through Tparm and the padding removed.
+func (ti *Terminfo) Parm(name string, params ...interface{}) string {
+	s, ok := ti.Strings[name]
+	if !ok {
+		return ""
+	}
+	return stripPadding(Tparm(s, params...))
+}
+
+// LoadEnv loads the entry of the terminal set in the environment variable TERM.
+func LoadEnv() (*Terminfo, error) {
+	term := os.Getenv("TERM")
+	if term == "" {
+		return nil, ErrNotFound
+	}
+	return Load(term)
+}
+
+// Load loads the entry of a terminal. It is searched, like in ncurses, in the
+// directories:
+//
+//   $TERMINFO
+//   $HOME/.terminfo
+//   the directories in $TERMINFO_DIRS, where an empty one means the system ones
+//   /etc/terminfo, /lib/terminfo, /usr/share/terminfo
+func Load(term string) (*Terminfo, error) {
+	if term == "" || strings.ContainsAny(term, "/\\") || term[0] == '.' {
+		return nil, ErrNotFound
+	}
+
+	for _, dir := range searchDirs() {
+		data, err := readEntry(dir, term)
+		if err != nil {
+			continue
+		}
+
+		ti, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("terminfo: could not load %q: %s", term, err)
+		}
+		return ti, nil
+	}
+	return nil, ErrNotFound
+}
+
+// systemDirs are the directories where the database is installed.
+var systemDirs = []string{"/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo"}
+
+// searchDirs returns the directories where to search the entries.
+func searchDirs() []string {
+	var dirs []string
+
+	if dir := os.Getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+
+	if env := os.Getenv("TERMINFO_DIRS"); env != "" {
+		for _, dir := range strings.Split(env, ":") {
+			if dir == "" {
+				dirs = append(dirs, systemDirs...)
+			} else {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return append(dirs, systemDirs...)
+}
+
+// readEntry reads the compiled entry of a terminal from a directory, where it
+// is stored into a subdirectory named by its first letter or, in Mac OS X, by
+// its first byte in hexadecimal.
+func readEntry(dir, term string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, term[:1], term))
+	if err == nil {
+		return data, nil
+	}
+	return ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("%x", term[0]), term))
+}
+
+// stripPadding removes the delays, in the form "$<5>", used by the terminals
+// which need padding.
+func stripPadding(s string) string {
+	for {
+		i := strings.Index(s, "$<")
+		if i == -1 {
+			return s
+		}
+		j := strings.IndexByte(s[i:], '>')
+		if j == -1 {
+			return s
+		}
+		s = s[:i] + s[i+j+1:]
+	}
+}
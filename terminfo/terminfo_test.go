@@ -0,0 +1,113 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package terminfo
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	os.Setenv("TERMINFO", "testdata")
+	defer os.Setenv("TERMINFO", "")
+
+	ti, err := Load("gotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ti.Names) != 2 || ti.Names[0] != "gotest" {
+		t.Errorf("unexpected names: %q", ti.Names)
+	}
+
+	if !ti.Bool("am") || !ti.Bool("xenl") || ti.Bool("bw") {
+		t.Error("unexpected boolean capabilities")
+	}
+	if ti.Number("colors") != 8 || ti.Number("cols") != 80 || ti.Number("pairs") != -1 {
+		t.Error("unexpected numeric capabilities")
+	}
+	if ti.String("el") != "\033[K" || ti.String("bel") != "\a" || ti.String("kbs") != "" {
+		t.Error("unexpected string capabilities")
+	}
+
+	// Extended capabilities
+	if !ti.Bool("AX") || ti.Number("U8") != 1 || ti.String("Smulx") != "\033[4:%p1%dm" {
+		t.Error("unexpected extended capabilities")
+	}
+
+	if s := ti.Parm("cup", 4, 9); s != "\033[5;10H" {
+		t.Errorf("cup: got %q", s)
+	}
+	if s := ti.Parm("flash"); s != "\033[?5h\033[?5l" {
+		t.Errorf("flash: got %q", s)
+	}
+
+	if _, err = Load("gotest-none"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLoad32bit(t *testing.T) {
+	os.Setenv("TERMINFO", "testdata")
+	defer os.Setenv("TERMINFO", "")
+
+	ti, err := Load("gotest-direct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ti.Number("colors") != 1<<24 || ti.Number("pairs") != 1<<16 || ti.Number("cols") != 80 {
+		t.Error("unexpected numeric capabilities")
+	}
+	if !ti.Bool("RGB") || !ti.Bool("AX") || ti.Number("U8") != 1 {
+		t.Error("unexpected extended capabilities")
+	}
+
+	if s := ti.Parm("setaf", 3); s != "\033[33m" {
+		t.Errorf("setaf: got %q", s)
+	}
+	if s := ti.Parm("setaf", 0x102030); s != "\033[38:2::16:32:48m" {
+		t.Errorf("setaf: got %q", s)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, data := range []string{
+		"", "\x1a\x01", "\x1a\x01\x05\x00\x01\x00", "abcdefghijkl",
+		"\x1a\x01\x02\x00\x00\x00\x00\x00\xff\xff\x00\x00\x61\x00", // negative count
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("expected error for %q", data)
+		}
+	}
+}
+
+var tparmTests = []struct {
+	in     string
+	params []interface{}
+	out    string
+}{
+	{"\033[%i%p1%d;%p2%dH", []interface{}{0, 0}, "\033[1;1H"},
+	{"\033Y%p1%' '%+%c%p2%' '%+%c", []interface{}{1, 2}, "\033Y!\""},
+	{"%p1%02d|%p1%:-3d|%p1%x|%p1%#o|%p1%X", []interface{}{10}, "10|10 |a|012|A"},
+	{"%p1%s%p1%l%d", []interface{}{"abc"}, "abc3"},
+	{"%{7}%{2}%m%{3}%*%{1}%-%{2}%/%d", nil, "1"},
+	{"%p1%Pa%ga%ga%+%d", []interface{}{4}, "8"},
+	{"%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;", []interface{}{2}, "32"},
+	{"%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;", []interface{}{9}, "91"},
+	{"%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;", []interface{}{100}, "38;5;100"},
+	{"%?%p1%t%?%p2%tab%;c%;d", []interface{}{0, 1}, "d"},
+	{"%?%p1%p2%A%!%tx%ey%;", []interface{}{1, 1}, "y"},
+	{"100%%", nil, "100%"},
+}
+
+func TestTparm(t *testing.T) {
+	for _, tt := range tparmTests {
+		if out := Tparm(tt.in, tt.params...); out != tt.out {
+			t.Errorf("Tparm(%q, %v) = %q; want %q", tt.in, tt.params, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package terminfo
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// param is a value used in the expansion of a parameterized string, which is
+// either a number or a string.
+type param struct {
+	n     int
+	s     string
+	isStr bool
+}
+
+func (p param) num() int {
+	if p.isStr {
+		return 0
+	}
+	return p.n
+}
+
+func boolParam(b bool) param {
+	if b {
+		return param{n: 1}
+	}
+	return param{}
+}
+
+// Tparm expands the parameters of a string capability, like tparm(3). The
+// parameters are integers or strings; up to 9 can be used.
+//
+// The static variables, from A to Z, are not kept between calls.
+func Tparm(s string, params ...interface{}) string {
+	var buf bytes.Buffer
+	var stack []param
+	var vars [52]param
+	var args [9]param
+
+	for i, v := range params {
+		if i == len(args) {
+			break
+		}
+		switch v := v.(type) {
+		case int:
+			args[i] = param{n: v}
+		case string:
+			args[i] = param{s: v, isStr: true}
+		case byte:
+			args[i] = param{n: int(v)}
+		case rune:
+			args[i] = param{n: int(v)}
+		case bool:
+			args[i] = boolParam(v)
+		}
+	}
+
+	push := func(p param) { stack = append(stack, p) }
+	pop := func() param {
+		if len(stack) == 0 {
+			return param{}
+		}
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return p
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+
+		switch c := s[i]; c {
+		case '%':
+			buf.WriteByte('%')
+		case 'c':
+			buf.WriteByte(byte(pop().num()))
+		case 's':
+			buf.WriteString(pop().s)
+		case 'd':
+			buf.WriteString(strconv.Itoa(pop().num()))
+
+		case 'p':
+			if i+1 < len(s) && s[i+1] >= '1' && s[i+1] <= '9' {
+				i++
+				push(args[s[i]-'1'])
+			}
+		case 'P', 'g':
+			if i+1 == len(s) {
+				break
+			}
+			i++
+			var v *param
+			switch x := s[i]; {
+			case x >= 'a' && x <= 'z':
+				v = &vars[x-'a']
+			case x >= 'A' && x <= 'Z':
+				v = &vars[26+x-'A']
+			default:
+				continue
+			}
+			if c == 'P' {
+				*v = pop()
+			} else {
+				push(*v)
+			}
+
+		case '\'': // character constant
+			if i+2 < len(s) && s[i+2] == '\'' {
+				push(param{n: int(s[i+1])})
+				i += 2
+			}
+		case '{': // integer constant
+			j := i + 1
+			for j < len(s) && s[j] != '}' {
+				j++
+			}
+			n, _ := strconv.Atoi(s[i+1 : j])
+			push(param{n: n})
+			i = j
+		case 'l':
+			push(param{n: len(pop().s)})
+		case 'i':
+			args[0].n++
+			args[1].n++
+
+		case '+', '-', '*', '/', 'm', '&', '|', '^', '=', '>', '<', 'A', 'O':
+			b, a := pop().num(), pop().num()
+			push(binaryOp(c, a, b))
+		case '!':
+			push(boolParam(pop().num() == 0))
+		case '~':
+			push(param{n: ^pop().num()})
+
+		case '?', ';':
+		case 't':
+			if pop().num() == 0 {
+				i = skipCond(s, i+1, true)
+			}
+		case 'e':
+			i = skipCond(s, i+1, false)
+
+		default: // %[[:]flags][width[.precision]][doxXs]
+			j := i
+			if s[j] == ':' {
+				j++
+			}
+			for j < len(s) && bytes.IndexByte([]byte("-+# .0123456789"), s[j]) != -1 {
+				j++
+			}
+			if j == len(s) || bytes.IndexByte([]byte("doxXs"), s[j]) == -1 {
+				break
+			}
+			format := "%" + s[i:j] + string(s[j])
+			if s[i] == ':' {
+				format = "%" + s[i+1:j] + string(s[j])
+			}
+
+			if p := pop(); s[j] == 's' {
+				fmt.Fprintf(&buf, format, p.s)
+			} else {
+				fmt.Fprintf(&buf, format, p.num())
+			}
+			i = j
+		}
+	}
+	return buf.String()
+}
+
+// binaryOp returns the result of an arithmetic, bit or logical operation.
+func binaryOp(op byte, a, b int) param {
+	switch op {
+	case '+':
+		return param{n: a + b}
+	case '-':
+		return param{n: a - b}
+	case '*':
+		return param{n: a * b}
+	case '/':
+		if b == 0 {
+			return param{}
+		}
+		return param{n: a / b}
+	case 'm':
+		if b == 0 {
+			return param{}
+		}
+		return param{n: a % b}
+	case '&':
+		return param{n: a & b}
+	case '|':
+		return param{n: a | b}
+	case '^':
+		return param{n: a ^ b}
+	case '=':
+		return boolParam(a == b)
+	case '>':
+		return boolParam(a > b)
+	case '<':
+		return boolParam(a < b)
+	case 'A':
+		return boolParam(a != 0 && b != 0)
+	case 'O':
+		return boolParam(a != 0 || b != 0)
+	}
+	return param{}
+}
+
+// skipCond skips the part of a conditional which is not run, starting at
+// index i. From "%t", it stops after the matching "%e" or "%;"; else, after
+// the matching "%;". It returns the index of the last byte skipped.
+func skipCond(s string, i int, toElse bool) int {
+	depth := 0
+
+	for ; i < len(s)-1; i++ {
+		if s[i] != '%' {
+			continue
+		}
+		i++
+
+		switch s[i] {
+		case '?':
+			depth++
+		case ';':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case 'e':
+			if depth == 0 && toElse {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
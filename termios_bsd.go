@@ -0,0 +1,65 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// +build darwin freebsd netbsd openbsd
+
+package terminal
+
+// vdisable is the value to disable a control character.
+const vdisable = 0xff
+
+// cflagMasks are the masks of the fields of several bits in the control modes.
+// The speed is stored apart in BSD systems.
+var cflagMasks = []tcflag{CSIZE}
+
+// getSpeed returns the input and output baud rates.
+// In BSD systems, the speed is stored as the number of bauds.
+func getSpeed(st *termios) (in, out int) {
+	return int(st.Ispeed), int(st.Ospeed)
+}
+
+// setSpeed sets the baud rate for both input and output.
+// Whatever baud rate can be set in BSD systems.
+func setSpeed(st *termios, baud int) bool {
+	st.Ispeed = speed(baud)
+	st.Ospeed = speed(baud)
+	return true
+}
+
+// setInputSpeed sets the input baud rate.
+func setInputSpeed(st *termios, baud int) bool {
+	st.Ispeed = speed(baud)
+	return true
+}
+
+// setOutputSpeed sets the output baud rate.
+func setOutputSpeed(st *termios, baud int) bool {
+	st.Ospeed = speed(baud)
+	return true
+}
+
+// readSpeed returns the input and output baud rates of the terminal.
+func readSpeed(fd int) (in, out int, err error) {
+	var st termios
+
+	if err = tcgetattr(fd, &st); err != nil {
+		return 0, 0, err
+	}
+	in, out = getSpeed(&st)
+	return in, out, nil
+}
+
+// setCustomSpeed sets an arbitrary baud rate in the terminal, like setSpeed
+// since it handles whatever baud rate in BSD systems.
+func setCustomSpeed(fd int, baud int) error {
+	var st termios
+
+	if err := tcgetattr(fd, &st); err != nil {
+		return err
+	}
+	setSpeed(&st, baud)
+	return tcsetattr(fd, _TCSANOW, &st)
+}
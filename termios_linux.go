@@ -0,0 +1,145 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package terminal
+
+import "unsafe"
+
+// Types used in the termios structure.
+type (
+	tcflag = uint32 // tcflag_t
+)
+
+// Control characters
+const (
+	vdisable = 0  // value to disable a control character
+	sttyNCCS = 32 // number of control characters in stty format (NCCS in glibc)
+)
+
+// cflagMasks are the masks of the fields of several bits in the control modes.
+var cflagMasks = []tcflag{CSIZE, _CBAUD}
+
+// baudRates maps the baud rates to the speed codes set in the control modes.
+var baudRates = map[int]uint32{
+	0:       B0,
+	50:      B50,
+	75:      B75,
+	110:     B110,
+	134:     B134,
+	150:     B150,
+	200:     B200,
+	300:     B300,
+	600:     B600,
+	1200:    B1200,
+	1800:    B1800,
+	2400:    B2400,
+	4800:    B4800,
+	9600:    B9600,
+	19200:   B19200,
+	38400:   B38400,
+	57600:   B57600,
+	115200:  B115200,
+	230400:  B230400,
+	460800:  _B460800,
+	500000:  _B500000,
+	576000:  _B576000,
+	921600:  _B921600,
+	1000000: _B1000000,
+	1152000: _B1152000,
+	1500000: _B1500000,
+	2000000: _B2000000,
+	2500000: _B2500000,
+	3000000: _B3000000,
+	3500000: _B3500000,
+	4000000: _B4000000,
+}
+
+// getSpeed returns the input and output baud rates set in the control modes.
+// The input one is the same as the output one when it is not set. It is -1 if
+// the baud rate is not a standard one, which has to be got through readSpeed.
+func getSpeed(st *termios) (in, out int) {
+	out = baudRate(st.Cflag & _CBAUD)
+	in = out
+	if code := st.Cflag & _CIBAUD >> _IBSHIFT; code != 0 {
+		in = baudRate(code)
+	}
+	return in, out
+}
+
+// baudRate returns the baud rate of the code, or -1 if it is not a standard one.
+func baudRate(code tcflag) int {
+	for baud, c := range baudRates {
+		if c == code {
+			return baud
+		}
+	}
+	return -1
+}
+
+// setSpeed sets the baud rate in the control modes, for both input and output.
+// Returns false if it is not a standard baud rate, which has to be set through
+// setCustomSpeed.
+func setSpeed(st *termios, baud int) bool {
+	if !setOutputSpeed(st, baud) {
+		return false
+	}
+	st.Cflag &^= _CIBAUD // the same as the output one
+	return true
+}
+
+// setInputSpeed sets the input baud rate in the control modes.
+// Returns false if it is not a standard baud rate.
+func setInputSpeed(st *termios, baud int) bool {
+	code, ok := baudRates[baud]
+	if !ok {
+		return false
+	}
+
+	st.Cflag &^= _CIBAUD
+	st.Cflag |= code << _IBSHIFT
+	return true
+}
+
+// setOutputSpeed sets the output baud rate in the control modes.
+// Returns false if it is not a standard baud rate.
+func setOutputSpeed(st *termios, baud int) bool {
+	code, ok := baudRates[baud]
+	if !ok {
+		return false
+	}
+
+	st.Cflag &^= _CBAUD
+	st.Cflag |= code
+	return true
+}
+
+// readSpeed returns the input and output baud rates of the terminal, through
+// the extended termios structure, which has the arbitrary ones.
+func readSpeed(fd int) (in, out int, err error) {
+	st := new(termios2)
+
+	if err = ioctl(fd, _TCGETS2, uintptr(unsafe.Pointer(st))); err != nil {
+		return 0, 0, err
+	}
+	return int(st.Ispeed), int(st.Ospeed), nil
+}
+
+// setCustomSpeed sets an arbitrary baud rate in the terminal, through the
+// extended termios structure.
+func setCustomSpeed(fd int, baud int) error {
+	st := new(termios2)
+
+	if err := ioctl(fd, _TCGETS2, uintptr(unsafe.Pointer(st))); err != nil {
+		return err
+	}
+
+	st.Cflag &^= _CBAUD
+	st.Cflag |= _BOTHER
+	st.Ispeed = uint32(baud)
+	st.Ospeed = uint32(baud)
+
+	return ioctl(fd, _TCSETS2, uintptr(unsafe.Pointer(st)))
+}
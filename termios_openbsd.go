@@ -0,0 +1,13 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package terminal
+
+// Types used in the termios structure.
+type (
+	tcflag = uint32 // tcflag_t
+	speed  = int32 // speed_t
+)
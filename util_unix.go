@@ -12,23 +12,34 @@ package terminal
 import "C"*/
 
 import (
-	"fmt"
 	"os"
 	"os/signal"
 //	"strconv"
 //	"path/filepath"
+	"strings"
 	"syscall"
+
+	"github.com/kless/terminal/terminfo"
 )
 
+// shellsNotANSI are the terminals without ANSI escape sequences, used when there
+// is not an entry for the terminal in the terminfo database.
 var shellsNotANSI = []string{"dumb", "cons25"}
 
 // SupportANSI checks if the terminal supports ANSI escape sequences.
+//
+// The terminal set in TERM is looked up in the terminfo database, whose cursor
+// addressing has to be an ANSI escape sequence.
 func SupportANSI() bool {
 	term := os.Getenv("TERM")
 	if term == "" {
 		return false
 	}
 
+	if ti, err := terminfo.Load(term); err == nil {
+		return strings.HasPrefix(ti.Parm("cup", 0, 0), "\033[")
+	}
+
 	for _, v := range shellsNotANSI {
 		if v == term {
 			return false
@@ -63,69 +74,15 @@ func IsTerminal(fd int) bool {
 	return tcgetattr(fd, &termios{}) == nil
 }
 
-// ReadPassword reads the input until '\n' without echo.
-// Returns the number of bytes read.
-func ReadPassword(fd int, pass []byte) (n int, err error) {
-	var oldState, newState termios
-
-	if err = tcgetattr(fd, &oldState); err != nil {
-		return 0, err
-	}
-
-	// Turn off echo
-	newState = oldState
-	newState.Lflag &^= (ECHO | ECHOE | ECHOK | ECHONL)
-
-	if err = tcsetattr(fd, _TCSANOW, &newState); err != nil {
-		return 0, fmt.Errorf("terminal: could not turn off echo: %s", err)
-	}
-
-	// Block SIGINT & SIGTSTP (CTRL-C, CTRL-Z)
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTSTP)
-	go func() {
-		for {
-			select {
-			case <-sig:
-				// ignore
-			}
-		}
-	}()
-
-	tmpPass := make([]byte, len(pass))
-
-	for i, exit := 0, false; ; i++ { // to store all data read until '\n'
-		n, err = syscall.Read(fd, tmpPass)
-		if err != nil {
-			tcsetattr(fd, _TCSANOW, &oldState)
-			return 0, err
-		}
-
-		if tmpPass[n-1] == '\n' {
-			n--
-			exit = true
-		}
-		if i == 0 {
-			copy(pass, tmpPass[:n])
-		}
-		if exit {
-			if i != 0 {
-				n = len(pass)
-			}
-			break
-		}
-	}
-
-	tmpPass = tmpPass[:0] // reset
-	tcsetattr(fd, _TCSANOW, &oldState)
-	return
-}
-
 // WinSizeChan allocates a channel to know when the window size has changed
 // through TrapSize.
+//
+// Deprecated: it can have a single receiver; use Terminal.NotifyResize.
 var WinSizeChan = make(chan byte, 1)
 
 // TrapSize caughts a signal named SIGWINCH whenever the window size changes.
+//
+// Deprecated: use Terminal.NotifyResize.
 func TrapSize() {
 	change := make(chan os.Signal, 1)
 	signal.Notify(change, syscall.SIGWINCH)
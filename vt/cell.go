@@ -0,0 +1,90 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package vt
+
+import "fmt"
+
+// A Color represents a color of a cell: the default one, an index of the
+// palette of 256 colors, or a 24-bit color.
+type Color int32
+
+// DefaultColor is the default color of the terminal.
+const DefaultColor Color = -1
+
+const rgbFlag = 1 << 24
+
+// IndexColor returns the color of the palette, from 0 to 255.
+func IndexColor(n uint8) Color { return Color(n) }
+
+// RGBColor returns a 24-bit color.
+func RGBColor(r, g, b uint8) Color {
+	return rgbFlag | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// Index returns the index of the color in the palette, and whether it is one.
+func (c Color) Index() (n uint8, ok bool) {
+	if c < 0 || c&rgbFlag != 0 {
+		return 0, false
+	}
+	return uint8(c), true
+}
+
+// RGB returns the components of a 24-bit color, and whether it is one.
+func (c Color) RGB() (r, g, b uint8, ok bool) {
+	if c < 0 || c&rgbFlag == 0 {
+		return 0, 0, 0, false
+	}
+	return uint8(c >> 16), uint8(c >> 8), uint8(c), true
+}
+
+func (c Color) String() string {
+	if n, ok := c.Index(); ok {
+		return fmt.Sprintf("%d", n)
+	}
+	if r, g, b, ok := c.RGB(); ok {
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	return "default"
+}
+
+// Attr represents the attributes of a cell.
+type Attr uint16
+
+const (
+	Bold Attr = 1 << iota
+	Faint
+	Italic
+	Underline
+	Blink
+	Reverse
+	Hidden
+	Strikethrough
+)
+
+// A Style represents the colors and attributes of a cell.
+type Style struct {
+	Fg, Bg Color
+	Attr   Attr
+
+	// The style of underline, from 1 for single to 5 for dashed, when Attr
+	// has Underline.
+	Underline uint8
+}
+
+// defaultStyle is the style after a reset.
+var defaultStyle = Style{Fg: DefaultColor, Bg: DefaultColor}
+
+// A Cell represents a character in the screen.
+type Cell struct {
+	Rune  rune // 0 in the cells never written
+	Style Style
+}
+
+// blankCell returns an erased cell, which keeps the background color.
+func blankCell(st Style) Cell {
+	return Cell{Style: Style{Fg: DefaultColor, Bg: st.Bg}}
+}
@@ -0,0 +1,356 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package vt
+
+import (
+	"strings"
+
+	"github.com/kless/terminal/ansi"
+)
+
+// handler handles the output parsed for the screen.
+type handler Screen
+
+func (h *handler) Print(r rune) { (*Screen)(h).print(r) }
+
+func (h *handler) Execute(b byte) {
+	s := (*Screen)(h)
+
+	switch b {
+	case '\b':
+		if s.col > 0 {
+			s.col--
+		}
+		s.wrapNext = false
+	case '\t':
+		s.moveTo(s.row, (s.col/8+1)*8)
+	case '\n', '\v', '\f':
+		s.lineFeed()
+	case '\r':
+		s.col = 0
+		s.wrapNext = false
+	}
+}
+
+func (h *handler) ESCDispatch(inter []byte, final byte) {
+	s := (*Screen)(h)
+	if len(inter) != 0 {
+		return // character sets
+	}
+
+	switch final {
+	case '7': // DECSC
+		s.saveCursor()
+	case '8': // DECRC
+		s.restoreCursor()
+	case 'D': // IND
+		s.lineFeed()
+	case 'E': // NEL
+		s.col = 0
+		s.lineFeed()
+	case 'M': // RI
+		s.reverseIndex()
+	case 'c': // RIS
+		s.Reset()
+	}
+}
+
+func (h *handler) CSIDispatch(params []int, inter []byte, final byte) {
+	s := (*Screen)(h)
+
+	switch string(inter) {
+	case "":
+	case "?":
+		if final == 'h' || final == 'l' {
+			for _, p := range params {
+				s.setMode(ansi.Mode(p), final == 'h')
+			}
+		}
+		return
+	default:
+		return
+	}
+
+	// n returns the parameter i, or 1 if it is not set.
+	n := func(i int) int {
+		if i < len(params) && params[i] != 0 {
+			return params[i]
+		}
+		return 1
+	}
+	mode := 0
+	if len(params) != 0 {
+		mode = params[0]
+	}
+
+	switch final {
+	case 'A': // CUU
+		s.moveTo(s.row-n(0), s.col)
+	case 'B': // CUD
+		s.moveTo(s.row+n(0), s.col)
+	case 'C': // CUF
+		s.moveTo(s.row, s.col+n(0))
+	case 'D': // CUB
+		s.moveTo(s.row, s.col-n(0))
+	case 'E': // CNL
+		s.moveTo(s.row+n(0), 0)
+	case 'F': // CPL
+		s.moveTo(s.row-n(0), 0)
+	case 'G', '`': // CHA, HPA
+		s.moveTo(s.row, n(0)-1)
+	case 'd': // VPA
+		s.moveTo(n(0)-1, s.col)
+	case 'H', 'f': // CUP, HVP
+		s.moveTo(n(0)-1, n(1)-1)
+
+	case 'J': // ED
+		switch mode {
+		case 0:
+			s.erase(s.row, s.col, s.cols)
+			for i := s.row + 1; i < s.rows; i++ {
+				s.erase(i, 0, s.cols)
+			}
+		case 1:
+			for i := 0; i < s.row; i++ {
+				s.erase(i, 0, s.cols)
+			}
+			s.erase(s.row, 0, s.col+1)
+		case 2, 3:
+			for i := 0; i < s.rows; i++ {
+				s.erase(i, 0, s.cols)
+			}
+		}
+		s.wrapNext = false
+	case 'K': // EL
+		switch mode {
+		case 0:
+			s.erase(s.row, s.col, s.cols)
+		case 1:
+			s.erase(s.row, 0, s.col+1)
+		case 2:
+			s.erase(s.row, 0, s.cols)
+		}
+		s.wrapNext = false
+	case 'X': // ECH
+		s.erase(s.row, s.col, s.col+n(0))
+		s.wrapNext = false
+
+	case '@': // ICH
+		line := s.lines[s.row]
+		count := clamp(n(0), 0, s.cols-s.col)
+		copy(line[s.col+count:], line[s.col:])
+		s.erase(s.row, s.col, s.col+count)
+		s.wrapNext = false
+	case 'P': // DCH
+		line := s.lines[s.row]
+		count := clamp(n(0), 0, s.cols-s.col)
+		copy(line[s.col:], line[s.col+count:])
+		s.erase(s.row, s.cols-count, s.cols)
+		s.wrapNext = false
+	case 'L': // IL
+		s.insertLines(s.row, n(0))
+		s.col, s.wrapNext = 0, false
+	case 'M': // DL
+		s.deleteLines(s.row, n(0))
+		s.col, s.wrapNext = 0, false
+	case 'S': // SU
+		s.scrollUp(n(0))
+	case 'T': // SD
+		s.scrollDown(n(0))
+
+	case 'r': // DECSTBM
+		top, bottom := n(0)-1, s.rows-1
+		if len(params) > 1 && params[1] != 0 {
+			bottom = params[1] - 1
+		}
+		if top < bottom && bottom < s.rows {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saveCursor()
+	case 'u':
+		s.restoreCursor()
+
+	case 'm': // SGR
+		h.sgr(params)
+	}
+}
+
+// setMode sets or resets a DEC private mode.
+func (s *Screen) setMode(m ansi.Mode, on bool) {
+	switch m {
+	case ansi.AutoWrap:
+		s.autoWrap = on
+		if !on {
+			s.wrapNext = false
+		}
+	case ansi.ShowCursor:
+		s.showCursor = on
+	case 47, 1047:
+		if !on && m == 1047 && s.alt {
+			for i := 0; i < s.rows; i++ {
+				s.erase(i, 0, s.cols)
+			}
+		}
+		s.setAltScreen(on)
+	case ansi.AltScreen:
+		if on {
+			s.saveCursor()
+			s.setAltScreen(true)
+			for i := 0; i < s.rows; i++ {
+				s.erase(i, 0, s.cols)
+			}
+		} else {
+			s.setAltScreen(false)
+			s.restoreCursor()
+		}
+	default:
+		if on {
+			s.modes[m] = true
+		} else {
+			delete(s.modes, m)
+		}
+	}
+}
+
+// sgr sets the style of the next characters.
+func (h *handler) sgr(params []int) {
+	s := (*Screen)(h)
+	if len(params) == 0 {
+		s.style = defaultStyle
+		return
+	}
+
+	for i := 0; i < len(params); i++ {
+		// The subparameters of the parameter i.
+		sub := i + 1
+		for sub < len(params) && s.parser.Subparam(sub) {
+			sub++
+		}
+		subs := params[i+1 : sub]
+
+		switch p := params[i]; {
+		case p == 0:
+			s.style = defaultStyle
+		case p == 1:
+			s.style.Attr |= Bold
+		case p == 2:
+			s.style.Attr |= Faint
+		case p == 3:
+			s.style.Attr |= Italic
+		case p == 4:
+			s.style.Attr |= Underline
+			s.style.Underline = 1
+			if len(subs) != 0 {
+				if subs[0] == 0 {
+					s.style.Attr &^= Underline
+				}
+				s.style.Underline = uint8(subs[0])
+			}
+		case p == 5:
+			s.style.Attr |= Blink
+		case p == 7:
+			s.style.Attr |= Reverse
+		case p == 8:
+			s.style.Attr |= Hidden
+		case p == 9:
+			s.style.Attr |= Strikethrough
+		case p == 21:
+			s.style.Attr |= Underline
+			s.style.Underline = 2
+		case p == 22:
+			s.style.Attr &^= Bold | Faint
+		case p == 23:
+			s.style.Attr &^= Italic
+		case p == 24:
+			s.style.Attr &^= Underline
+			s.style.Underline = 0
+		case p == 25:
+			s.style.Attr &^= Blink
+		case p == 27:
+			s.style.Attr &^= Reverse
+		case p == 28:
+			s.style.Attr &^= Hidden
+		case p == 29:
+			s.style.Attr &^= Strikethrough
+
+		case p >= 30 && p <= 37:
+			s.style.Fg = Color(p - 30)
+		case p >= 40 && p <= 47:
+			s.style.Bg = Color(p - 40)
+		case p >= 90 && p <= 97:
+			s.style.Fg = Color(p - 90 + 8)
+		case p >= 100 && p <= 107:
+			s.style.Bg = Color(p - 100 + 8)
+		case p == 39:
+			s.style.Fg = DefaultColor
+		case p == 49:
+			s.style.Bg = DefaultColor
+
+		case p == 38, p == 48:
+			var c Color
+			var ok bool
+			if len(subs) != 0 {
+				c, ok, _ = extendedColor(subs, true)
+			} else {
+				var used int
+				c, ok, used = extendedColor(params[i+1:], false)
+				sub += used
+			}
+			if ok {
+				if p == 38 {
+					s.style.Fg = c
+				} else {
+					s.style.Bg = c
+				}
+			}
+		}
+		i = sub - 1
+	}
+}
+
+// extendedColor returns the color set in the parameters after 38 or 48, like
+// "5;n" or "2;r;g;b", and the number of parameters used. In the format with
+// subparameters, the 24-bit color can have the identifier of color space,
+// "2:id:r:g:b".
+func extendedColor(params []int, colon bool) (c Color, ok bool, used int) {
+	if len(params) == 0 {
+		return
+	}
+
+	switch params[0] {
+	case 5:
+		if len(params) < 2 {
+			return
+		}
+		return Color(clamp(params[1], 0, 255)), true, 2
+	case 2:
+		rgb := params[1:]
+		if colon && len(rgb) >= 4 {
+			rgb = rgb[1:]
+		}
+		if len(rgb) < 3 {
+			return
+		}
+		return RGBColor(uint8(rgb[0]), uint8(rgb[1]), uint8(rgb[2])), true, 4
+	}
+	return
+}
+
+func (h *handler) OSCDispatch(data []byte) {
+	s := (*Screen)(h)
+
+	str := string(data)
+	if strings.HasPrefix(str, "0;") || strings.HasPrefix(str, "2;") {
+		s.title = str[2:]
+	}
+}
+
+func (h *handler) DCSHook(params []int, inter []byte, final byte) {}
+func (h *handler) DCSPut(b byte)                                  {}
+func (h *handler) DCSUnhook()                                     {}
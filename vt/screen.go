@@ -0,0 +1,320 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+/* Reference: http://invisible-island.net/xterm/ctlseqs/ctlseqs.html */
+
+// Package vt emulates a video terminal without display, to check the output
+// of the programs which write control sequences, like package editline.
+//
+// A Screen keeps the grid of cells written, the cursor, the attributes, the
+// scroll region and the alternate screen:
+//
+//   scr := vt.New(24, 80)
+//   fmt.Fprint(scr, "\033[2J\033[HHello\r\nworld")
+//   scr.String() // "Hello\nworld"
+//
+// Each character takes a cell, so the wide characters are not handled.
+package vt
+
+import (
+	"strings"
+
+	"github.com/kless/terminal/ansi"
+)
+
+// A Screen represents the screen of a terminal, which is updated with the
+// output written to it.
+type Screen struct {
+	rows, cols int
+
+	lines    [][]Cell // lines of the screen shown
+	altLines [][]Cell // lines of the screen not shown
+	alt      bool     // the alternate screen is shown
+
+	row, col int   // position of the cursor, starting at 0
+	wrapNext bool  // the cursor is after the last column
+	style    Style // style of the next characters
+
+	top, bottom int // scroll region
+
+	saved struct {
+		row, col int
+		style    Style
+	}
+
+	autoWrap   bool
+	showCursor bool
+	modes      map[ansi.Mode]bool // other private modes set
+	title      string
+
+	parser *ansi.Parser
+}
+
+// New returns a screen with the size, in rows and columns.
+func New(rows, cols int) *Screen {
+	if rows < 1 {
+		rows = 1
+	}
+	if cols < 1 {
+		cols = 1
+	}
+
+	s := &Screen{rows: rows, cols: cols}
+	s.parser = ansi.NewParser((*handler)(s))
+	s.Reset()
+	return s
+}
+
+// Reset sets the screen to its initial state, like after of the sequence RIS.
+func (s *Screen) Reset() {
+	s.lines = newLines(s.rows, s.cols)
+	s.altLines = newLines(s.rows, s.cols)
+	s.alt = false
+
+	s.row, s.col, s.wrapNext = 0, 0, false
+	s.style = defaultStyle
+	s.top, s.bottom = 0, s.rows-1
+	s.saved.row, s.saved.col, s.saved.style = 0, 0, defaultStyle
+
+	s.autoWrap = true
+	s.showCursor = true
+	s.modes = make(map[ansi.Mode]bool)
+	s.title = ""
+	s.parser.Reset()
+}
+
+func newLines(rows, cols int) [][]Cell {
+	lines := make([][]Cell, rows)
+	for i := range lines {
+		lines[i] = newLine(cols, defaultStyle)
+	}
+	return lines
+}
+
+func newLine(cols int, st Style) []Cell {
+	line := make([]Cell, cols)
+	for i := range line {
+		line[i] = blankCell(st)
+	}
+	return line
+}
+
+// Write updates the screen with the output. It always returns len(p) and a
+// nil error.
+func (s *Screen) Write(p []byte) (int, error) {
+	return s.parser.Write(p)
+}
+
+// Resize changes the size of the screen, keeping the cells at the top left.
+// The scroll region is reset.
+func (s *Screen) Resize(rows, cols int) {
+	if rows < 1 {
+		rows = 1
+	}
+	if cols < 1 {
+		cols = 1
+	}
+
+	resize := func(lines [][]Cell) [][]Cell {
+		out := newLines(rows, cols)
+		for i := 0; i < rows && i < len(lines); i++ {
+			copy(out[i], lines[i])
+		}
+		return out
+	}
+	s.lines = resize(s.lines)
+	s.altLines = resize(s.altLines)
+
+	s.rows, s.cols = rows, cols
+	s.top, s.bottom = 0, rows-1
+	s.row, s.col = clamp(s.row, 0, rows-1), clamp(s.col, 0, cols-1)
+	s.wrapNext = false
+}
+
+// Size returns the number of rows and columns.
+func (s *Screen) Size() (rows, cols int) { return s.rows, s.cols }
+
+// Cursor returns the position of the cursor, starting at 0.
+func (s *Screen) Cursor() (row, col int) { return s.row, s.col }
+
+// CursorVisible reports whether the cursor is shown.
+func (s *Screen) CursorVisible() bool { return s.showCursor }
+
+// AltScreen reports whether the alternate screen is shown.
+func (s *Screen) AltScreen() bool { return s.alt }
+
+// Mode reports whether the DEC private mode is set.
+func (s *Screen) Mode(m ansi.Mode) bool {
+	switch m {
+	case ansi.AutoWrap:
+		return s.autoWrap
+	case ansi.ShowCursor:
+		return s.showCursor
+	case ansi.AltScreen:
+		return s.alt
+	}
+	return s.modes[m]
+}
+
+// Title returns the title of the window, set through OSC 0 or 2.
+func (s *Screen) Title() string { return s.title }
+
+// Cell returns the cell in the position, starting at 0.
+func (s *Screen) Cell(row, col int) Cell {
+	if row < 0 || row >= s.rows || col < 0 || col >= s.cols {
+		return blankCell(defaultStyle)
+	}
+	return s.lines[row][col]
+}
+
+// Line returns the text of a row, without the spaces at the end.
+func (s *Screen) Line(row int) string {
+	if row < 0 || row >= s.rows {
+		return ""
+	}
+
+	text := make([]rune, s.cols)
+	for i, c := range s.lines[row] {
+		if c.Rune == 0 {
+			text[i] = ' '
+		} else {
+			text[i] = c.Rune
+		}
+	}
+	return strings.TrimRight(string(text), " ")
+}
+
+// String returns the text shown in the screen, without the spaces at the end of
+// the lines and without the empty lines at the end.
+func (s *Screen) String() string {
+	lines := make([]string, s.rows)
+	for i := range lines {
+		lines[i] = s.Line(i)
+	}
+
+	n := len(lines)
+	for n > 0 && lines[n-1] == "" {
+		n--
+	}
+	return strings.Join(lines[:n], "\n")
+}
+
+// == Operations
+//
+
+// print writes a character in the cursor position.
+func (s *Screen) print(r rune) {
+	if s.wrapNext {
+		s.col = 0
+		s.lineFeed()
+	}
+	s.lines[s.row][s.col] = Cell{r, s.style}
+
+	if s.col == s.cols-1 {
+		s.wrapNext = s.autoWrap
+	} else {
+		s.col++
+	}
+}
+
+// lineFeed moves the cursor down, scrolling up at the bottom of the scroll
+// region.
+func (s *Screen) lineFeed() {
+	s.wrapNext = false
+	if s.row == s.bottom {
+		s.scrollUp(1)
+	} else if s.row < s.rows-1 {
+		s.row++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling down at the top of the scroll
+// region.
+func (s *Screen) reverseIndex() {
+	s.wrapNext = false
+	if s.row == s.top {
+		s.scrollDown(1)
+	} else if s.row > 0 {
+		s.row--
+	}
+}
+
+// scrollUp scrolls up n lines the scroll region.
+func (s *Screen) scrollUp(n int) { s.deleteLines(s.top, n) }
+
+// scrollDown scrolls down n lines the scroll region.
+func (s *Screen) scrollDown(n int) { s.insertLines(s.top, n) }
+
+// insertLines inserts n blank lines at the row, moving down the lines until
+// the bottom of the scroll region.
+func (s *Screen) insertLines(row, n int) {
+	if row < s.top || row > s.bottom {
+		return
+	}
+	n = clamp(n, 0, s.bottom-row+1)
+
+	copy(s.lines[row+n:s.bottom+1], s.lines[row:s.bottom+1-n])
+	for i := row; i < row+n; i++ {
+		s.lines[i] = newLine(s.cols, s.style)
+	}
+}
+
+// deleteLines deletes n lines at the row, moving up the lines until the bottom
+// of the scroll region.
+func (s *Screen) deleteLines(row, n int) {
+	if row < s.top || row > s.bottom {
+		return
+	}
+	n = clamp(n, 0, s.bottom-row+1)
+
+	copy(s.lines[row:s.bottom+1-n], s.lines[row+n:s.bottom+1])
+	for i := s.bottom + 1 - n; i <= s.bottom; i++ {
+		s.lines[i] = newLine(s.cols, s.style)
+	}
+}
+
+// erase erases the cells of a row, from the column start to end, not included.
+func (s *Screen) erase(row, start, end int) {
+	line := s.lines[row]
+	for i := clamp(start, 0, s.cols); i < clamp(end, 0, s.cols); i++ {
+		line[i] = blankCell(s.style)
+	}
+}
+
+// moveTo moves the cursor to the position, limited to the screen.
+func (s *Screen) moveTo(row, col int) {
+	s.row = clamp(row, 0, s.rows-1)
+	s.col = clamp(col, 0, s.cols-1)
+	s.wrapNext = false
+}
+
+// setAltScreen shows or hides the alternate screen.
+func (s *Screen) setAltScreen(on bool) {
+	if on == s.alt {
+		return
+	}
+	s.lines, s.altLines = s.altLines, s.lines
+	s.alt = on
+}
+
+func (s *Screen) saveCursor() {
+	s.saved.row, s.saved.col, s.saved.style = s.row, s.col, s.style
+}
+
+func (s *Screen) restoreCursor() {
+	s.moveTo(s.saved.row, s.saved.col)
+	s.style = s.saved.style
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+// Copyright 2012 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package vt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kless/terminal/ansi"
+)
+
+var screenTests = []struct {
+	in       string
+	text     string
+	row, col int
+}{
+	{"abc\r\ndef", "abc\ndef", 1, 3},
+	{"abc\bX", "abX", 0, 3},
+	{"a\tb", "a       b", 0, 9},
+
+	// Wrapping
+	{"0123456789", "0123456789", 0, 9},
+	{"0123456789ab", "0123456789\nab", 1, 2},
+	{"0123456789\r\nab", "0123456789\nab", 1, 2},
+	{"\033[?7l0123456789ab", "012345678b", 0, 9},
+
+	// Scrolling
+	{"1\r\n2\r\n3\r\n4\r\n5\r\n6", "2\n3\n4\n5\n6", 4, 1},
+	{"1\r\n2\r\n3\r\n4\r\n5\033[2;4r\033[4H\n\nx", "1\n4\n\nx\n5", 3, 1},
+	{"1\r\n2\r\n3\033[H\033Mx", "x\n1\n2\n3", 0, 1},
+	{"1\r\n2\r\n3\r\n4\033[2H\033[L", "1\n\n2\n3\n4", 1, 0},
+	{"1\r\n2\r\n3\r\n4\033[2H\033[2M", "1\n4", 1, 0},
+	{"1\r\n2\r\n3\033[S", "2\n3", 2, 1},
+
+	// Cursor
+	{"\033[3;5Hx\033[Ay\033[2Bz", "\n     y\n    x\n      z", 3, 7},
+	{"\033[99;99Hx", "\n\n\n\n         x", 4, 9},
+	{"abc\033[2Dx\033[Gy", "yxc", 0, 1},
+	{"ab\033[2;3H\033[Fc\033[Ed", "cb\nd", 1, 1},
+	{"ab\0337\033[3;3Hc\0338d", "abd\n\n  c", 0, 3},
+	{"\033[3;1Hx\033[s\033[Hy\033[ux", "y\n\nxx", 2, 2},
+
+	// Erase
+	{"abcdef\033[3D\033[K", "abc", 0, 3},
+	{"abcdef\033[3D\033[1K", "    ef", 0, 3},
+	{"abcdef\033[3D\033[2K", "", 0, 3},
+	{"ab\r\ncd\r\nef\033[2;2H\033[J", "ab\nc", 1, 1},
+	{"ab\r\ncd\r\nef\033[2;1H\033[1J", "\n d\nef", 1, 0},
+	{"ab\r\ncd\033[2J", "", 1, 2},
+	{"abcdef\033[4D\033[2X", "ab  ef", 0, 2},
+
+	// Editing
+	{"abcdef\033[4D\033[2@", "ab  cdef", 0, 2},
+	{"abcdef\033[4D\033[2P", "abef", 0, 2},
+	{"0123456789\033[5G\033[3@", "0123   456", 0, 4},
+
+	// Alternate screen
+	{"main\033[?1049halt", "    alt", 0, 7},
+	{"main\033[?1049halt\033[?1049l", "main", 0, 4},
+	{"ma\033[?1049h\033[3;3H\033[?1049lin", "main", 0, 4},
+
+	// Reset
+	{"abc\033[1m\033c", "", 0, 0},
+}
+
+func TestScreen(t *testing.T) {
+	for _, tt := range screenTests {
+		s := New(5, 10)
+		fmt.Fprint(s, tt.in)
+
+		if got := s.String(); got != tt.text {
+			t.Errorf("%q: expected text %q, got %q", tt.in, tt.text, got)
+		}
+		if row, col := s.Cursor(); row != tt.row || col != tt.col {
+			t.Errorf("%q: expected cursor at %d,%d, got %d,%d", tt.in, tt.row, tt.col, row, col)
+		}
+	}
+}
+
+func TestStyle(t *testing.T) {
+	s := New(2, 20)
+	fmt.Fprint(s, "\033[1;31ma\033[4:3;48;5;200mb\033[22;38;2;1;2;3mc"+
+		"\033[0;38:2::10:20:30;4md\033[m\033[44;2me\033[39;49;22mf")
+
+	tests := []struct {
+		col  int
+		want Style
+	}{
+		{0, Style{Fg: 1, Bg: DefaultColor, Attr: Bold}},
+		{1, Style{Fg: 1, Bg: 200, Attr: Bold | Underline, Underline: 3}},
+		{2, Style{Fg: RGBColor(1, 2, 3), Bg: 200, Attr: Underline, Underline: 3}},
+		{3, Style{Fg: RGBColor(10, 20, 30), Bg: DefaultColor, Attr: Underline, Underline: 1}},
+		{4, Style{Fg: DefaultColor, Bg: 4, Attr: Faint}},
+		{5, Style{Fg: DefaultColor, Bg: DefaultColor}},
+	}
+	for _, tt := range tests {
+		if got := s.Cell(0, tt.col).Style; got != tt.want {
+			t.Errorf("column %d: expected %+v, got %+v", tt.col, tt.want, got)
+		}
+	}
+
+	// The erased cells keep the background.
+	fmt.Fprint(s, "\033[41m\033[2K")
+	if got := s.Cell(0, 0); got.Rune != 0 || got.Style.Bg != 1 {
+		t.Errorf("expected erased cell with red background, got %+v", got)
+	}
+}
+
+func TestModes(t *testing.T) {
+	s := New(5, 10)
+	fmt.Fprint(s, "\033[?25l\033[?1000;1006h\033]2;title\a")
+
+	if s.CursorVisible() {
+		t.Error("expected cursor hidden")
+	}
+	if !s.Mode(ansi.MouseNormal) || !s.Mode(ansi.MouseSGR) || s.Mode(ansi.BracketedPaste) {
+		t.Error("unexpected private modes")
+	}
+	if s.Title() != "title" {
+		t.Errorf("expected title, got %q", s.Title())
+	}
+
+	fmt.Fprint(s, "\033[?1006l")
+	if s.Mode(ansi.MouseSGR) {
+		t.Error("expected mode reset")
+	}
+}
+
+func TestResize(t *testing.T) {
+	s := New(3, 10)
+	fmt.Fprint(s, "0123456789\r\nab\r\ncd")
+
+	s.Resize(2, 4)
+	if got := s.String(); got != "0123\nab" {
+		t.Errorf("unexpected text: %q", got)
+	}
+	if row, col := s.Cursor(); row != 1 || col != 2 {
+		t.Errorf("unexpected cursor at %d,%d", row, col)
+	}
+}
@@ -8,27 +8,42 @@ package terminal
 
 const (
 	B0          = 0x0
+	_B1000000   = 0x1008
 	B110        = 0x3
 	B115200     = 0x1002
+	_B1152000   = 0x1009
 	B1200       = 0x9
 	B134        = 0x4
 	B150        = 0x5
+	_B1500000   = 0x100a
 	B1800       = 0xa
 	B19200      = 0xe
 	B200        = 0x6
+	_B2000000   = 0x100b
 	B230400     = 0x1003
 	B2400       = 0xb
+	_B2500000   = 0x100c
 	B300        = 0x7
+	_B3000000   = 0x100d
+	_B3500000   = 0x100e
 	B38400      = 0xf
+	_B4000000   = 0x100f
+	_B460800    = 0x1004
 	B4800       = 0xc
 	B50         = 0x1
+	_B500000    = 0x1005
 	B57600      = 0x1001
+	_B576000    = 0x1006
 	B600        = 0x8
 	B75         = 0x2
+	_B921600    = 0x1007
 	B9600       = 0xd
+	_BOTHER     = 0x1000
 	BRKINT      = 0x2
 	BS0         = 0x0
 	BS1         = 0x2000
+	_CBAUD      = 0x100f
+	_CIBAUD     = 0x100f0000
 	CLOCAL      = 0x800
 	CR0         = 0x0
 	CR1         = 0x200
@@ -56,6 +71,7 @@ const (
 	FF1         = 0x8000
 	FLUSHO      = 0x1000
 	HUPCL       = 0x400
+	_IBSHIFT    = 0x10
 	ICANON      = 0x2
 	ICRNL       = 0x100
 	IEXTEN      = 0x8000
@@ -86,13 +102,18 @@ const (
 	TAB1        = 0x800
 	TAB2        = 0x1000
 	_TCGETS     = 0x5401
+	_TCGETS2    = 0x802c542a
 	_TCSADRAIN  = 0x1
 	_TCSAFLUSH  = 0x2
 	_TCSANOW    = 0x0
 	_TCSETS     = 0x5402
+	_TCSETS2    = 0x402c542b
 	_TCSETSF    = 0x5404
 	_TCSETSW    = 0x5403
+	_TIOCGPTN   = 0x80045430
 	_TIOCGWINSZ = 0x5413
+	_TIOCSPTLCK = 0x40045431
+	_TIOCSWINSZ = 0x5414
 	TOSTOP      = 0x100
 	VDISCARD    = 0xd
 	VEOF        = 0x4
@@ -121,6 +142,16 @@ type termios struct {
 	Line  uint8
 	Cc    [19]uint8
 }
+type termios2 struct {
+	Iflag  uint32
+	Oflag  uint32
+	Cflag  uint32
+	Lflag  uint32
+	Line   uint8
+	Cc     [19]uint8
+	Ispeed uint32
+	Ospeed uint32
+}
 type winsize struct {
 	Row    uint16
 	Col    uint16
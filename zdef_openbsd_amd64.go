@@ -90,6 +90,7 @@ const (
 	_TCSANOW    = 0x0
 	_TCGETS     = 0x402c7413
 	_TIOCGWINSZ = 0x40087468
+	_TIOCSWINSZ = 0x80087467
 	_TCSETS     = 0x802c7414
 	_TCSETSF    = 0x802c7416
 	_TCSETSW    = 0x802c7415